@@ -45,6 +45,29 @@ func TestWriterHandlerNoMessage(t *testing.T) {
 	assert.Contains(t, out, "INFO     [p]")
 }
 
+func TestWriterHandlerAttrQuoting(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewWriterHandler(&buf)
+	assert.NoError(t, h.Handle(Record{Level: LevelError, Message: "m", Attrs: []Attr{
+		{Key: "input", Value: "a\nINFO forged"},
+		{Key: "stack", Value: StackTrace("goroutine 1 [running]:\nmain.main()\n\t/app/main.go:9\n")},
+	}}))
+	assert.Equal(t, "ERROR    m input=\"a\\nINFO forged\" stack=goroutine 1 [running]:\n    main.main()\n    \t/app/main.go:9\n", buf.String())
+}
+
+func TestTextHandlersEscapeMessageControlChars(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewWriterHandler(&buf)
+	assert.NoError(t, h.Handle(Record{Level: LevelInfo, Message: "a\nERROR forged\r\x00"}))
+	assert.NoError(t, h.Handle(Record{Level: LevelInfo, Message: "col\tumn \x1b[1mbold\x1b[0m\n"}))
+	assert.Equal(t, "INFO     a\\nERROR forged\\r\\x00\nINFO     col\tumn \x1b[1mbold\x1b[0m\n", buf.String())
+
+	buf.Reset()
+	c := NewColoredWriterHandler(&buf, ColorOptions{Mode: ColorOff})
+	assert.NoError(t, c.Handle(Record{Level: LevelInfo, Message: "a\nb"}))
+	assert.Equal(t, "INFO     a\\nb\n", buf.String())
+}
+
 func TestJSONHandlerPrefixAndNilWriter(t *testing.T) {
 	// NewJSONHandler(nil) -> stderr; we won't validate output, only coverage
 	_ = NewJSONHandler(nil)
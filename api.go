@@ -1,12 +1,6 @@
 package log
 
-import (
-	"fmt"
-	"os"
-)
-
-// exitFunc allows tests to intercept process termination.
-var exitFunc = os.Exit
+import "fmt"
 
 // Print, Printf, Println implement stdlib's surface and log at Info level.
 func Print(v ...any)                 { std.logf(LevelInfo, "%s", fmt.Sprint(v...)) }
@@ -16,15 +10,15 @@ func Println(v ...any)               { std.logf(LevelInfo, "%s", trimNL(fmt.Spri
 // Fatal variants log at Fatal and then exit(1).
 func Fatal(v ...any) {
 	std.logf(LevelFatal, "%s", fmt.Sprint(v...))
-	exitFunc(1)
+	std.exitProcess(1)
 }
 func Fatalf(format string, v ...any) {
 	std.logf(LevelFatal, format, v...)
-	exitFunc(1)
+	std.exitProcess(1)
 }
 func Fatalln(v ...any) {
 	std.logf(LevelFatal, "%s", trimNL(fmt.Sprintln(v...)))
-	exitFunc(1)
+	std.exitProcess(1)
 }
 
 // Panic variants log at Panic then panic.
@@ -34,9 +28,12 @@ func Panicln(v ...any)               { doPanic(trimNL(fmt.Sprintln(v...))) }
 
 func doPanic(msg string) {
 	std.logf(LevelPanic, "%s", msg)
-	panic(msg)
+	std.panicWith(msg)
 }
 
+// Log logs a structured record at an arbitrary level on the default logger.
+func Log(level Level, msg string, kv ...any) { std.logStructured(level, msg, kv...) }
+
 // Slog-like helpers on the default logger.
 func Debug(msg string, kv ...any)    { std.logStructured(LevelDebug, msg, kv...) }
 func Info(msg string, kv ...any)     { std.logStructured(LevelInfo, msg, kv...) }
@@ -66,6 +63,42 @@ func (l *Logger) Print(v ...any)                 { l.logf(LevelInfo, "%s", fmt.S
 func (l *Logger) Printf(format string, v ...any) { l.logf(LevelInfo, format, v...) }
 func (l *Logger) Println(v ...any)               { l.logf(LevelInfo, "%s", trimNL(fmt.Sprintln(v...))) }
 
+// Fatal variants log at Fatal and then exit(1), using the Logger's exit
+// function when set with WithExitFunc.
+func (l *Logger) Fatal(v ...any) {
+	l.logf(LevelFatal, "%s", fmt.Sprint(v...))
+	l.exitProcess(1)
+}
+func (l *Logger) Fatalf(format string, v ...any) {
+	l.logf(LevelFatal, format, v...)
+	l.exitProcess(1)
+}
+func (l *Logger) Fatalln(v ...any) {
+	l.logf(LevelFatal, "%s", trimNL(fmt.Sprintln(v...)))
+	l.exitProcess(1)
+}
+
+// Panic variants log at Panic then panic, or call the function set with
+// WithPanicFunc.
+func (l *Logger) Panic(v ...any) {
+	msg := fmt.Sprint(v...)
+	l.logf(LevelPanic, "%s", msg)
+	l.panicWith(msg)
+}
+func (l *Logger) Panicf(format string, v ...any) {
+	msg := fmt.Sprintf(format, v...)
+	l.logf(LevelPanic, "%s", msg)
+	l.panicWith(msg)
+}
+func (l *Logger) Panicln(v ...any) {
+	msg := trimNL(fmt.Sprintln(v...))
+	l.logf(LevelPanic, "%s", msg)
+	l.panicWith(msg)
+}
+
+// Log logs a structured record at an arbitrary level.
+func (l *Logger) Log(level Level, msg string, kv ...any) { l.logStructured(level, msg, kv...) }
+
 // Level helpers on Logger
 func (l *Logger) Debug(msg string, kv ...any)    { l.logStructured(LevelDebug, msg, kv...) }
 func (l *Logger) Info(msg string, kv ...any)     { l.logStructured(LevelInfo, msg, kv...) }
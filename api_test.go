@@ -192,3 +192,14 @@ func TestPanicVariants(t *testing.T) {
 		assert.Panics(t, func() { Panicf("%s", "pmsg") })
 	})
 }
+
+func TestLogAtArbitraryLevel(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		Log(LevelNotice, "pkg", "k", 1)
+		Default().Log(Level(3), "custom")
+		assert.Equal(t, "NOTICE   pkg k=1\nLEVEL(3) custom\n", buf.String())
+	})
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// RegisterFlags registers command-line flags on fs (flag.CommandLine if nil)
+// that configure the default logger:
+//
+//	-log.level  minimum level, e.g. info (default debug)
+//	-log.format text, json or logfmt (default text)
+//	-log.file   file to append to, or stdout/stderr (default stderr)
+//	-log.color  on, off or auto; colors text output
+//	-log.flags  header flags, e.g. date,time,micro,shortfile,utc
+//
+// Invalid values are reported by fs.Parse. The returned function applies the
+// parsed flags and must be called once after fs.Parse: if any of -log.level,
+// -log.format, -log.file or -log.color was given, the default logger's outputs
+// are replaced with a single output built from all of them. Its prefix is
+// kept, as are its flags unless -log.flags was given.
+func RegisterFlags(fs *flag.FlagSet) (apply func() error) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := &logFlags{}
+	fs.Func("log.level", "minimum log `level`: trace, debug, info, notice, warn, error, ...", func(s string) error {
+		if _, err := ParseLevel(s); err != nil {
+			return fmt.Errorf("unknown level %q", s)
+		}
+		f.level = s
+		return nil
+	})
+	fs.Func("log.format", "log `format`: text, json or logfmt", func(s string) error {
+		switch s = strings.ToLower(s); s {
+		case "text", "json", "logfmt":
+		default:
+			return fmt.Errorf("unknown format %q (want text, json or logfmt)", s)
+		}
+		f.format = s
+		return nil
+	})
+	fs.Func("log.file", "append logs to `path` (or stdout, stderr)", func(s string) error {
+		f.file = s
+		return nil
+	})
+	fs.Func("log.color", "colored text output: on, off or auto", func(s string) error {
+		if _, err := ParseColorMode(s); err != nil {
+			return err
+		}
+		f.color = s
+		return nil
+	})
+	fs.Func("log.flags", "comma-separated header `flags`: date, time, micro, shortfile, longfile, utc, msgprefix, std or none", func(s string) error {
+		flags, err := ParseFlags(s)
+		if err != nil {
+			return err
+		}
+		f.flags, f.setFlags = flags, true
+		return nil
+	})
+	return f.apply
+}
+
+// logFlags holds the values parsed by RegisterFlags.
+type logFlags struct {
+	level, format, file, color string
+	flags                      int
+	setFlags                   bool
+}
+
+func (f *logFlags) apply() error {
+	if f.level == "" && f.format == "" && f.file == "" && f.color == "" {
+		if f.setFlags {
+			SetFlags(f.flags)
+		}
+		return nil
+	}
+	oc := OutputConfig{Type: f.format, Destination: f.file, Color: f.color}
+	switch {
+	case f.color != "" && f.format != "" && f.format != "text":
+		return errors.New("-log.color applies to text format only")
+	case f.color != "":
+		oc.Type = "color"
+	case f.format == "":
+		oc.Type = "text"
+	}
+	b, err := Config{Level: f.level, Outputs: []OutputConfig{oc}}.build(true, false)
+	if err != nil {
+		var ce *ConfigError
+		if errors.As(err, &ce) {
+			return ce.Err
+		}
+		return err
+	}
+	std.mu.Lock()
+	b.prefix, b.flags = std.prefix, std.flags
+	std.mu.Unlock()
+	if f.setFlags {
+		b.flags = f.flags
+	}
+	std.install(b, true)
+	return nil
+}
@@ -0,0 +1,122 @@
+package log
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saveStd restores the default logger's outputs, prefix and flags after t.
+func saveStd(t *testing.T) {
+	std.mu.Lock()
+	outputs, prefix, flags, configured := std.outputs, std.prefix, std.flags, std.configured
+	std.mu.Unlock()
+	t.Cleanup(func() {
+		std.mu.Lock()
+		cur := std.configured
+		std.outputs, std.prefix, std.flags, std.configured = outputs, prefix, flags, configured
+		std.mu.Unlock()
+		if cur != nil && cur != configured {
+			cur.close()
+		}
+	})
+}
+
+// parseFlags registers the log flags on a new FlagSet, parses args and
+// applies them.
+func parseFlags(args ...string) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	apply := RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return fs, err
+	}
+	return fs, apply()
+}
+
+func TestRegisterFlags(t *testing.T) {
+	saveStd(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	fs, err := parseFlags("-log.file", path, "-log.level=warn", "-log.format", "logfmt", "-log.flags", "none", "rest")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rest"}, fs.Args())
+
+	Info("dropped")
+	Warn("kept", "k", 1)
+	Close()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "level=warn msg=kept k=1\n", string(data))
+}
+
+func TestRegisterFlagsColorAndFlags(t *testing.T) {
+	saveStd(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	_, err := parseFlags("-log.color=on", "-log.file="+path, "-log.flags=date,utc")
+	require.NoError(t, err)
+	std.mu.Lock()
+	assert.Equal(t, Ldate|LUTC, std.flags)
+	require.Len(t, std.outputs, 1)
+	assert.IsType(t, &ColoredWriterHandler{}, std.outputs[0].h)
+	std.mu.Unlock()
+}
+
+func TestRegisterFlagsInvalid(t *testing.T) {
+	saveStd(t)
+	for _, args := range [][]string{
+		{"-log.level=loud"},
+		{"-log.format=xml"},
+		{"-log.color=sometimes"},
+		{"-log.flags=date,weekday"},
+		{"-log.format=json", "-log.color=on"},
+	} {
+		_, err := parseFlags(args...)
+		assert.Error(t, err, "%v", args)
+	}
+}
+
+func TestRegisterFlagsAppliesOnce(t *testing.T) {
+	saveStd(t)
+	SetPrefix("app: ")
+	SetFlags(Lmsgprefix)
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	apply := RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-log.file", path, "-log.level", "warn", "-log.format", "logfmt"}))
+	assert.NoFileExists(t, path, "nothing is opened until apply")
+
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	require.NoError(t, apply())
+	Warn("new")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old\nlevel=warn prefix=\"app: \" msg=new\n", string(data), "-log.file appends")
+	std.mu.Lock()
+	assert.Equal(t, "app: ", std.prefix)
+	assert.Equal(t, Lmsgprefix, std.flags)
+	require.Len(t, std.outputs, 1)
+	std.mu.Unlock()
+}
+
+func TestRegisterFlagsOnlyFlags(t *testing.T) {
+	saveStd(t)
+	std.mu.Lock()
+	outputs := std.outputs
+	std.mu.Unlock()
+
+	_, err := parseFlags("-log.flags=time")
+	require.NoError(t, err)
+	std.mu.Lock()
+	assert.Equal(t, Ltime, std.flags)
+	assert.Equal(t, len(outputs), len(std.outputs), "outputs are unchanged")
+	std.mu.Unlock()
+}
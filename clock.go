@@ -0,0 +1,67 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock supplies the time stamped on records.
+type Clock interface {
+	Now() time.Time
+}
+
+// FakeClock is a Clock that only moves when told to, for deterministic tests.
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewFakeClock returns a FakeClock stopped at t.
+func NewFakeClock(t time.Time) *FakeClock { return &FakeClock{t: t} }
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// Set moves the clock to t.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+}
+
+// Option configures a Logger created by New.
+type Option func(*Logger)
+
+// WithClock makes the Logger stamp records with c instead of the package time
+// source set by SetNowFunc.
+func WithClock(c Clock) Option {
+	return func(l *Logger) {
+		if c != nil {
+			l.now = c.Now
+		}
+	}
+}
+
+// WithExitFunc makes the Logger's Fatal methods call f instead of the package
+// exit function set by SetExitFunc.
+func WithExitFunc(f func(code int)) Option {
+	return func(l *Logger) { l.exit = f }
+}
+
+// WithPanicFunc makes the Logger's Panic methods call f with the message
+// instead of panicking. If f returns, so does the Panic call.
+func WithPanicFunc(f func(msg string)) Option {
+	return func(l *Logger) { l.panicFn = f }
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := NewFakeClock(start)
+	assert.Equal(t, start, c.Now())
+	c.Advance(90 * time.Second)
+	assert.Equal(t, start.Add(90*time.Second), c.Now())
+	c.Set(start)
+	assert.Equal(t, start, c.Now())
+}
+
+func TestLoggerOptionsAreScoped(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			clock := NewFakeClock(time.Date(2020, 1, 1, 0, 0, i, 0, time.UTC))
+			var buf bytes.Buffer
+			var code int
+			var panicked string
+			l := New(&buf, "", Ltime, WithClock(clock), WithExitFunc(func(c int) { code = c }), WithPanicFunc(func(m string) { panicked = m }))
+			l.Info("a")
+			clock.Advance(time.Minute)
+			l.With("k", "v").Fatalf("stop %d", i)
+			l.Panicln("boom")
+
+			assert.Equal(t, fmt.Sprintf("00:00:%02d INFO     a\n00:01:%02d FATAL    stop %d k=v\n00:01:%02d PANIC    boom\n", i, i, i, i), buf.String())
+			assert.Equal(t, 1, code)
+			assert.Equal(t, "boom", panicked)
+		})
+	}
+}
+
+func TestLoggerFatalAndPanicDefaults(t *testing.T) {
+	var code int
+	SetExitFunc(func(c int) { code = c })
+	defer SetExitFunc(nil)
+	l := New(io.Discard, "", 0)
+	l.Fatal("x")
+	assert.Equal(t, 1, code)
+	l.Fatalln("y")
+	assert.PanicsWithValue(t, "z 1", func() { l.Panicf("z %d", 1) })
+	assert.PanicsWithValue(t, "w", func() { l.Panic("w") })
+}
+
+func TestPackageSettersRaceFree(t *testing.T) {
+	defer SetNowFunc(nil)
+	defer SetExitFunc(nil)
+	SetExitFunc(func(int) {})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetNowFunc(time.Now)
+			SetExitFunc(func(int) {})
+		}()
+		go func() {
+			defer wg.Done()
+			l := New(io.Discard, "", LstdFlags)
+			l.Info("x")
+			l.Fatal("y")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,475 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config declares a Logger's outputs. It decodes from JSON with the field
+// names shown in the struct tags.
+//
+//	{"level": "info", "flags": "date,time,utc", "outputs": [
+//	  {"type": "color", "destination": "stdout"},
+//	  {"type": "rotating", "destination": "logs/app.log", "format": "json", "max_size_mb": 50}
+//	]}
+type Config struct {
+	// Level is the minimum level of outputs that do not set one (default "debug").
+	Level string `json:"level,omitempty"`
+	// Prefix is the logger prefix.
+	Prefix string `json:"prefix,omitempty"`
+	// Flags is a comma-separated list for ParseFlags (default "std").
+	Flags string `json:"flags,omitempty"`
+	// Outputs lists the handlers to build. None means one text output to stderr.
+	Outputs []OutputConfig `json:"outputs,omitempty"`
+}
+
+// OutputConfig declares one output.
+type OutputConfig struct {
+	// Type is text, color, json, logfmt, file, rotating or syslog.
+	Type string `json:"type"`
+	// Level is the output's minimum level (default Config.Level).
+	Level string `json:"level,omitempty"`
+	// Destination is "stdout", "stderr" (default) or a file path for text,
+	// color, json and logfmt; the file path for file and rotating; and the
+	// daemon address such as "udp://localhost:514" for syslog (default local).
+	Destination string `json:"destination,omitempty"`
+	// Format is the encoding of file and rotating outputs: text (default),
+	// json or logfmt.
+	Format string `json:"format,omitempty"`
+	// Truncate empties an existing file when the output is opened; by default
+	// file outputs append. Rotating files and files reopened by a
+	// ConfigWatcher always append.
+	Truncate bool `json:"truncate,omitempty"`
+	// Color is on, off or auto for color outputs (default auto).
+	Color string `json:"color,omitempty"`
+	// MaxSizeMB and MaxBackups control rotating outputs (defaults 100 and 5).
+	MaxSizeMB  int `json:"max_size_mb,omitempty"`
+	MaxBackups int `json:"max_backups,omitempty"`
+	// Tag is the syslog tag (default the program name).
+	Tag string `json:"tag,omitempty"`
+	// Filter is an optional ParseFilter expression records must match.
+	Filter string `json:"filter,omitempty"`
+}
+
+// ConfigError reports an invalid Config field. Field is a path such as
+// "outputs[1].level".
+type ConfigError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConfigError) Error() string { return "log: config: " + e.Field + ": " + e.Err.Error() }
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// ParseFlags parses a comma-separated list of date, time, micro, longfile,
+// shortfile, utc, msgprefix, std or none into Ldate|Ltime|... flags.
+func ParseFlags(s string) (int, error) {
+	flags := 0
+	for _, name := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "date":
+			flags |= Ldate
+		case "time":
+			flags |= Ltime
+		case "micro", "microseconds":
+			flags |= Lmicroseconds
+		case "longfile":
+			flags |= Llongfile
+		case "shortfile":
+			flags |= Lshortfile
+		case "utc":
+			flags |= LUTC
+		case "msgprefix":
+			flags |= Lmsgprefix
+		case "std":
+			flags |= LstdFlags
+		case "none", "":
+		default:
+			return 0, fmt.Errorf("unknown flag %q", strings.TrimSpace(name))
+		}
+	}
+	return flags, nil
+}
+
+// ParseColorMode parses on, off or auto (also true/false, always/never).
+func ParseColorMode(s string) (ColorMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "on", "true", "always", "yes":
+		return ColorOn, nil
+	case "off", "false", "never", "no":
+		return ColorOff, nil
+	case "auto", "":
+		return ColorAuto, nil
+	}
+	return ColorAuto, fmt.Errorf("unknown color mode %q (want on, off or auto)", s)
+}
+
+// LoadConfig reads a JSON Config from path. Unknown fields are rejected.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	return decodeConfig(path, data)
+}
+
+func decodeConfig(path string, data []byte) (Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("log: config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// builtOutputs are the handlers made from a Config and the resources they own.
+type builtOutputs struct {
+	cfg      Config
+	prefix   string
+	flags    int
+	outputs  []output
+	closers  []io.Closer    // also in the openFiles registry
+	inflight sync.WaitGroup // records being handled by outputs
+}
+
+func (b *builtOutputs) close() {
+	for _, c := range b.closers {
+		unregisterFile(c)
+		_ = c.Close()
+	}
+}
+
+// Validate reports every invalid field of cfg without opening any output.
+func (cfg Config) Validate() error {
+	_, err := cfg.build(false, false)
+	return err
+}
+
+// build validates cfg and, when open is set, creates its outputs. reopen
+// appends to file outputs even if they set Truncate. On error nothing is
+// left open.
+func (cfg Config) build(open, reopen bool) (*builtOutputs, error) {
+	var errs []error
+	fail := func(field string, err error) { errs = append(errs, &ConfigError{Field: field, Err: err}) }
+
+	b := &builtOutputs{cfg: cfg, prefix: cfg.Prefix, flags: LstdFlags}
+	if cfg.Flags != "" {
+		f, err := ParseFlags(cfg.Flags)
+		if err != nil {
+			fail("flags", err)
+		}
+		b.flags = f
+	}
+	defLevel := LevelDebug
+	if cfg.Level != "" {
+		lvl, err := ParseLevel(cfg.Level)
+		if err != nil {
+			fail("level", fmt.Errorf("unknown level %q", cfg.Level))
+		}
+		defLevel = lvl
+	}
+	outs := cfg.Outputs
+	if len(outs) == 0 {
+		outs = []OutputConfig{{Type: "text"}}
+	}
+	for i, oc := range outs {
+		field := fmt.Sprintf("outputs[%d]", i)
+		min := defLevel
+		if oc.Level != "" {
+			lvl, err := ParseLevel(oc.Level)
+			if err != nil {
+				fail(field+".level", fmt.Errorf("unknown level %q", oc.Level))
+				continue
+			}
+			min = lvl
+		}
+		var filter Filter
+		if oc.Filter != "" {
+			f, err := ParseFilter(oc.Filter)
+			if err != nil {
+				fail(field+".filter", errors.New(strings.TrimPrefix(err.Error(), "log: filter: ")))
+				continue
+			}
+			filter = f
+		}
+		h, closer, err := oc.build(field, open, reopen, fail)
+		if err != nil {
+			fail(field+".destination", err)
+			continue
+		}
+		if closer != nil {
+			registerFile(closer)
+			b.closers = append(b.closers, closer)
+		}
+		if h == nil {
+			continue // invalid, already reported, or not opened
+		}
+		if filter != nil {
+			h = NewFilterHandler(h, filter)
+		}
+		b.outputs = append(b.outputs, output{h: h, min: min})
+	}
+	if len(errs) > 0 {
+		b.close()
+		return nil, errors.Join(errs...)
+	}
+	return b, nil
+}
+
+// build checks oc and, when open is set, creates its handler. Field errors are
+// reported through fail; the returned error is an I/O error opening the
+// destination.
+func (oc OutputConfig) build(field string, open, reopen bool, fail func(string, error)) (Handler, io.Closer, error) {
+	format := strings.ToLower(oc.Format)
+	switch format {
+	case "", "text", "json", "logfmt":
+	default:
+		fail(field+".format", fmt.Errorf("unknown format %q (want text, json or logfmt)", oc.Format))
+		return nil, nil, nil
+	}
+	encode := func(w io.Writer) Handler {
+		switch format {
+		case "json":
+			return NewJSONHandler(w)
+		case "logfmt":
+			return NewLogfmtHandler(w)
+		}
+		return NewWriterHandler(w)
+	}
+	// writer opens a console stream or a file destination.
+	writer := func() (io.Writer, io.Closer, error) {
+		switch strings.ToLower(oc.Destination) {
+		case "", "stderr":
+			return os.Stderr, nil, nil
+		case "stdout":
+			return os.Stdout, nil, nil
+		}
+		openFile := OpenFileAppend
+		if oc.Truncate && !reopen {
+			openFile = OpenFileTruncate
+		}
+		f, err := openFile(oc.Destination, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f, nil
+	}
+
+	switch typ := strings.ToLower(oc.Type); typ {
+	case "text", "json", "logfmt":
+		if oc.Format != "" && format != typ {
+			fail(field+".format", fmt.Errorf("format %q conflicts with type %q", oc.Format, oc.Type))
+			return nil, nil, nil
+		}
+		format = typ
+		if !open {
+			return nil, nil, nil
+		}
+		w, c, err := writer()
+		if err != nil {
+			return nil, nil, err
+		}
+		return encode(w), c, nil
+	case "color":
+		mode, err := ParseColorMode(oc.Color)
+		if err != nil {
+			fail(field+".color", err)
+			return nil, nil, nil
+		}
+		if !open {
+			return nil, nil, nil
+		}
+		w, c, err := writer()
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewColoredWriterHandler(w, ColorOptions{Mode: mode}), c, nil
+	case "file":
+		if d := strings.ToLower(oc.Destination); d == "" || d == "stdout" || d == "stderr" {
+			fail(field+".destination", errors.New("file output needs a file path"))
+			return nil, nil, nil
+		}
+		if !open {
+			return nil, nil, nil
+		}
+		w, c, err := writer()
+		if err != nil {
+			return nil, nil, err
+		}
+		return encode(w), c, nil
+	case "rotating":
+		if d := strings.ToLower(oc.Destination); d == "" || d == "stdout" || d == "stderr" {
+			fail(field+".destination", errors.New("rotating output needs a file path"))
+			return nil, nil, nil
+		}
+		if oc.MaxSizeMB < 0 {
+			fail(field+".max_size_mb", errors.New("must not be negative"))
+			return nil, nil, nil
+		}
+		if oc.MaxBackups < 0 {
+			fail(field+".max_backups", errors.New("must not be negative"))
+			return nil, nil, nil
+		}
+		if !open {
+			return nil, nil, nil
+		}
+		size, backups := oc.MaxSizeMB, oc.MaxBackups
+		if size == 0 {
+			size = 100
+		}
+		if backups == 0 {
+			backups = 5
+		}
+		rf, err := NewRotatingFile(oc.Destination, int64(size)<<20, backups)
+		if err != nil {
+			return nil, nil, err
+		}
+		return encode(rf), rf, nil
+	case "syslog":
+		network, addr := "", ""
+		if oc.Destination != "" {
+			var ok bool
+			network, addr, ok = strings.Cut(oc.Destination, "://")
+			if !ok || (network != "udp" && network != "tcp" && network != "unix" && network != "unixgram") {
+				fail(field+".destination", fmt.Errorf("syslog destination %q is not network://address", oc.Destination))
+				return nil, nil, nil
+			}
+		}
+		if !open {
+			return nil, nil, nil
+		}
+		h, err := NewSyslogHandler(network, addr, oc.Tag)
+		if err != nil {
+			return nil, nil, err
+		}
+		return h, h, nil
+	case "":
+		fail(field+".type", errors.New("missing (want text, color, json, logfmt, file, rotating or syslog)"))
+	default:
+		fail(field+".type", fmt.Errorf("unknown type %q (want text, color, json, logfmt, file, rotating or syslog)", oc.Type))
+	}
+	return nil, nil, nil
+}
+
+// Configure replaces the default logger's outputs, prefix and flags with those
+// declared by cfg. See (*Logger).Configure.
+func Configure(cfg Config) error { return std.Configure(cfg) }
+
+// Configure replaces l's outputs, prefix and flags with those declared by cfg.
+// An invalid cfg leaves l unchanged and returns ConfigErrors joined with
+// errors.Join. Files opened by a previous Configure are closed once the
+// records already being written to them are done. Loggers derived from l with
+// With switch to the new outputs. Opened files are also closed by Close.
+func (l *Logger) Configure(cfg Config) error {
+	return l.configure(cfg, false)
+}
+
+func (l *Logger) configure(cfg Config, reopen bool) error {
+	// Check every field before opening (and maybe truncating) any file.
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	b, err := cfg.build(true, reopen)
+	if err != nil {
+		return err
+	}
+	l.install(b, true)
+	return nil
+}
+
+// drainTimeout bounds how long install waits for records still being handled
+// by the previous outputs; slower ones finish in the background.
+var drainTimeout = time.Second
+
+// install swaps in b's outputs, and its prefix and flags when format is set,
+// then closes the previous configuration's files once in-flight records are
+// done. If they take longer than drainTimeout (a blocked handler), the files
+// are closed in the background when they finish.
+func (l *Logger) install(b *builtOutputs, format bool) {
+	l.mu.Lock()
+	old := l.configured
+	l.outputs, l.configured = b.outputs, b
+	if format {
+		l.prefix, l.flags = b.prefix, b.flags
+	}
+	l.mu.Unlock()
+	if old == nil {
+		return
+	}
+	drained := make(chan struct{})
+	go func() {
+		old.inflight.Wait()
+		close(drained)
+	}()
+	t := time.NewTimer(drainTimeout)
+	defer t.Stop()
+	select {
+	case <-drained:
+		old.close()
+	case <-t.C:
+		go func() {
+			<-drained
+			old.close()
+		}()
+	}
+}
+
+// ConfigFromEnv returns base with environment overrides applied:
+// LOG_LEVEL sets the level of every output, and LOG_FORMAT (text, color, json
+// or logfmt) and LOG_OUTPUT (stdout, stderr or a file path) change the first
+// output, adding one if base has none. LOG_FORMAT only replaces text, json and
+// logfmt outputs, or the format of a file; color and syslog outputs keep theirs.
+func ConfigFromEnv(base Config) Config {
+	cfg := base
+	cfg.Outputs = append([]OutputConfig(nil), base.Outputs...)
+	if lvl := os.Getenv("LOG_LEVEL"); lvl != "" {
+		cfg.Level = lvl
+		for i := range cfg.Outputs {
+			cfg.Outputs[i].Level = lvl
+		}
+	}
+	format, dest := os.Getenv("LOG_FORMAT"), os.Getenv("LOG_OUTPUT")
+	if format == "" && dest == "" {
+		return cfg
+	}
+	if len(cfg.Outputs) == 0 {
+		cfg.Outputs = []OutputConfig{{Type: "text"}}
+	}
+	o := &cfg.Outputs[0]
+	if format != "" {
+		switch o.Type {
+		case "file", "rotating":
+			o.Format = format
+		case "", "text", "json", "logfmt":
+			o.Type, o.Format = format, ""
+		}
+	}
+	if dest != "" {
+		o.Destination = dest
+	}
+	return cfg
+}
+
+// ConfigureFromEnv configures the default logger from the JSON file named by
+// LOG_CONFIG (if set) with ConfigFromEnv overrides applied.
+func ConfigureFromEnv() error {
+	var base Config
+	if path := os.Getenv("LOG_CONFIG"); path != "" {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			return err
+		}
+		base = cfg
+	}
+	return Configure(ConfigFromEnv(base))
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlags(t *testing.T) {
+	f, err := ParseFlags("date, time,micro,shortfile,utc")
+	require.NoError(t, err)
+	assert.Equal(t, Ldate|Ltime|Lmicroseconds|Lshortfile|LUTC, f)
+
+	f, err = ParseFlags("none")
+	require.NoError(t, err)
+	assert.Zero(t, f)
+
+	_, err = ParseFlags("date,weekday")
+	assert.EqualError(t, err, `unknown flag "weekday"`)
+}
+
+func TestParseColorMode(t *testing.T) {
+	for in, want := range map[string]ColorMode{"on": ColorOn, "OFF": ColorOff, "auto": ColorAuto, "": ColorAuto} {
+		got, err := ParseColorMode(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseColorMode("sometimes")
+	assert.Error(t, err)
+}
+
+func TestConfigureOutputs(t *testing.T) {
+	dir := t.TempDir()
+	textPath := filepath.Join(dir, "app.log")
+	jsonPath := filepath.Join(dir, "logs", "app.json")
+
+	l := New(io.Discard, "", 0)
+	err := l.Configure(Config{
+		Level:  "info",
+		Prefix: "svc",
+		Flags:  "none",
+		Outputs: []OutputConfig{
+			{Type: "file", Destination: textPath},
+			{Type: "rotating", Destination: jsonPath, Format: "json", Level: "warn"},
+			{Type: "logfmt", Destination: filepath.Join(dir, "db.log"), Filter: `attr.component == "db"`},
+		},
+	})
+	require.NoError(t, err)
+
+	l.Debug("hidden")
+	l.Info("started")
+	l.With("component", "db").Warn("slow query")
+	require.NoError(t, l.Configure(Config{Outputs: []OutputConfig{{Type: "file", Destination: filepath.Join(dir, "next.log")}}}))
+
+	text, err := os.ReadFile(textPath)
+	require.NoError(t, err)
+	assert.Equal(t, "INFO     [svc] started\nWARN     [svc] slow query component=db\n", string(text))
+
+	js, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(js), `"msg":"slow query"`)
+	assert.NotContains(t, string(js), "started")
+
+	db, err := os.ReadFile(filepath.Join(dir, "db.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "level=warn prefix=svc msg=\"slow query\" component=db\n", string(db))
+}
+
+func TestConfigureInvalidKeepsPrevious(t *testing.T) {
+	c := &captureHandler{}
+	l := New(io.Discard, "", 0)
+	l.AddHandler(LevelDebug, c)
+
+	err := l.Configure(Config{
+		Level: "loud",
+		Outputs: []OutputConfig{
+			{Type: "text"},
+			{Type: "json", Level: "sometimes"},
+			{Type: "carrier-pigeon"},
+			{Type: "file"},
+			{Type: "text", Filter: "level >>"},
+		},
+	})
+	require.Error(t, err)
+	var ce *ConfigError
+	require.True(t, errors.As(err, &ce))
+	assert.Equal(t, "level", ce.Field)
+	for _, want := range []string{
+		`log: config: level: unknown level "loud"`,
+		`log: config: outputs[1].level: unknown level "sometimes"`,
+		`log: config: outputs[2].type: unknown type "carrier-pigeon"`,
+		`log: config: outputs[3].destination: file output needs a file path`,
+		`log: config: outputs[4].filter: `,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	l.Info("still here")
+	assert.Equal(t, []string{"still here"}, c.messages())
+}
+
+func TestConfigValidateOpensNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "never.log")
+	require.NoError(t, Config{Outputs: []OutputConfig{{Type: "file", Destination: path}}}.Validate())
+	assert.NoFileExists(t, path)
+}
+
+func TestConfigureInvalidTruncatesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	err := New(io.Discard, "", 0).Configure(Config{Outputs: []OutputConfig{
+		{Type: "file", Destination: path, Truncate: true},
+		{Type: "json", Level: "sometimes"},
+	}})
+	require.Error(t, err)
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "old\n", string(b))
+}
+
+func TestConfigureFileAppendsByDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	l := New(io.Discard, "", 0)
+	require.NoError(t, l.Configure(Config{Flags: "none", Outputs: []OutputConfig{{Type: "file", Destination: path}}}))
+	l.Info("new")
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "old\nINFO     new\n", string(b))
+
+	require.NoError(t, l.Configure(Config{Flags: "none", Outputs: []OutputConfig{{Type: "file", Destination: path, Truncate: true}}}))
+	l.Info("fresh")
+	b, _ = os.ReadFile(path)
+	assert.Equal(t, "INFO     fresh\n", string(b))
+	require.NoError(t, l.Configure(Config{}))
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"level":"warn","outputs":[{"type":"json","destination":"stdout"}]}`), 0o644))
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, Config{Level: "warn", Outputs: []OutputConfig{{Type: "json", Destination: "stdout"}}}, cfg)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"levle":"warn"}`), 0o644))
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, `unknown field "levle"`)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("LOG_FORMAT", "json")
+	t.Setenv("LOG_OUTPUT", "stdout")
+
+	base := Config{Outputs: []OutputConfig{{Type: "text", Level: "info"}, {Type: "rotating", Destination: "a.log"}}}
+	cfg := ConfigFromEnv(base)
+	assert.Equal(t, Config{Level: "error", Outputs: []OutputConfig{
+		{Type: "json", Level: "error", Destination: "stdout"},
+		{Type: "rotating", Level: "error", Destination: "a.log"},
+	}}, cfg)
+	assert.Equal(t, "text", base.Outputs[0].Type, "base is not modified")
+
+	assert.Equal(t, []OutputConfig{{Type: "json", Destination: "stdout"}}, ConfigFromEnv(Config{}).Outputs)
+
+	for _, typ := range []string{"color", "syslog"} {
+		out := ConfigFromEnv(Config{Outputs: []OutputConfig{{Type: typ}}}).Outputs[0]
+		assert.Equal(t, typ, out.Type, "LOG_FORMAT does not replace a %s output", typ)
+	}
+}
+
+func TestConfigureFromEnv(t *testing.T) {
+	std.mu.Lock()
+	outputs, prefix, flags := std.outputs, std.prefix, std.flags
+	std.mu.Unlock()
+	t.Cleanup(func() {
+		std.mu.Lock()
+		std.outputs, std.prefix, std.flags, std.configured = outputs, prefix, flags, nil
+		std.mu.Unlock()
+	})
+
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "log.json")
+	out := filepath.Join(dir, "out.log")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`{"flags":"none","outputs":[{"type":"text"}]}`), 0o644))
+	t.Setenv("LOG_CONFIG", cfgPath)
+	t.Setenv("LOG_OUTPUT", out)
+	t.Setenv("LOG_LEVEL", "warn")
+
+	require.NoError(t, ConfigureFromEnv())
+	Info("dropped")
+	Warn("kept")
+	require.NoError(t, Configure(Config{}))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "WARN     kept\n", string(data))
+}
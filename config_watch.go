@@ -0,0 +1,210 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchOptions controls how a ConfigWatcher notices changes.
+type WatchOptions struct {
+	// Interval between checks of the file's contents (default 2s; negative
+	// disables polling).
+	Interval time.Duration
+	// Signals trigger a reload when received, e.g. syscall.SIGHUP.
+	Signals []os.Signal
+	// Env applies LOG_LEVEL, LOG_FORMAT and LOG_OUTPUT overrides (see
+	// ConfigFromEnv) to every load.
+	Env bool
+}
+
+// ConfigWatcher reloads a Logger's configuration when its file changes.
+type ConfigWatcher struct {
+	l    *Logger
+	path string
+	env  bool
+
+	mu   sync.Mutex // serializes loads
+	last Config
+	raw  []byte // file contents at the last load
+
+	sigs      chan os.Signal
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchConfig configures the default logger from path and keeps it in sync.
+// See (*Logger).WatchConfig.
+func WatchConfig(path string, opts WatchOptions) (*ConfigWatcher, error) {
+	return std.WatchConfig(path, opts)
+}
+
+// WatchConfig configures l from the JSON file at path and reloads it when the
+// file changes or one of opts.Signals arrives. Each reload swaps outputs
+// atomically: records already being written finish on the old outputs before
+// their files are closed, and file outputs are reopened for appending. A
+// reload that changes anything logs a Notice "log: config reloaded" listing
+// the changes; an invalid file logs an Error and keeps the current setup.
+// Loggers derived from l with With follow each reload.
+//
+// The first load must succeed.
+func (l *Logger) WatchConfig(path string, opts WatchOptions) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{l: l, path: path, env: opts.Env, stop: make(chan struct{}), done: make(chan struct{})}
+	raw, cfg, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.Configure(cfg); err != nil {
+		return nil, err
+	}
+	w.last, w.raw = cfg, raw
+
+	interval := opts.Interval
+	if interval == 0 {
+		interval = 2 * time.Second
+	}
+	if len(opts.Signals) > 0 {
+		w.sigs = make(chan os.Signal, 1)
+		signal.Notify(w.sigs, opts.Signals...)
+	}
+	go w.run(interval)
+	return w, nil
+}
+
+// load reads and decodes the file.
+func (w *ConfigWatcher) load() ([]byte, Config, error) {
+	raw, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, Config{}, err
+	}
+	cfg, err := decodeConfig(w.path, raw)
+	if err != nil {
+		return raw, Config{}, err
+	}
+	if w.env {
+		cfg = ConfigFromEnv(cfg)
+	}
+	return raw, cfg, nil
+}
+
+func (w *ConfigWatcher) run(interval time.Duration) {
+	defer close(w.done)
+	var tick <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.sigs:
+			_ = w.Reload()
+		case <-tick:
+			if w.changed() {
+				_ = w.Reload()
+			}
+		}
+	}
+}
+
+// changed compares contents rather than modification times, which can be too
+// coarse to tell quick successive writes apart.
+func (w *ConfigWatcher) changed() bool {
+	raw, err := os.ReadFile(w.path)
+	if err != nil {
+		return false // mid-replace; look again next tick
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !bytes.Equal(raw, w.raw)
+}
+
+// Reload loads the file now. An unchanged configuration is left alone. On
+// error the current setup is kept, an Error record is logged and the error is
+// returned.
+func (w *ConfigWatcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	raw, cfg, err := w.load()
+	if raw != nil {
+		w.raw = raw // report a bad file once, not every tick
+	}
+	if err == nil {
+		if reflect.DeepEqual(cfg, w.last) {
+			return nil
+		}
+		err = w.l.configure(cfg, true)
+	}
+	if err != nil {
+		w.l.Error("log: config reload failed", "path", w.path, "error", err.Error())
+		return err
+	}
+	changes := configChanges(w.last, cfg)
+	w.last = cfg
+	w.l.Notice("log: config reloaded", "path", w.path, "changes", strings.Join(changes, "; "))
+	return nil
+}
+
+// Close stops watching. The current configuration stays in place.
+func (w *ConfigWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		if w.sigs != nil {
+			signal.Stop(w.sigs)
+		}
+		close(w.stop)
+	})
+	<-w.done
+	return nil
+}
+
+// configChanges describes the fields that differ between two configs, e.g.
+// `level: "info" -> "debug"` or `outputs[1] added`.
+func configChanges(old, cfg Config) []string {
+	var changes []string
+	diffFields(&changes, "", reflect.ValueOf(old), reflect.ValueOf(cfg), "Outputs")
+	for i := 0; i < len(old.Outputs) || i < len(cfg.Outputs); i++ {
+		field := fmt.Sprintf("outputs[%d]", i)
+		switch {
+		case i >= len(old.Outputs):
+			changes = append(changes, fmt.Sprintf("%s added (%s)", field, describeOutput(cfg.Outputs[i])))
+		case i >= len(cfg.Outputs):
+			changes = append(changes, fmt.Sprintf("%s removed (%s)", field, describeOutput(old.Outputs[i])))
+		default:
+			diffFields(&changes, field+".", reflect.ValueOf(old.Outputs[i]), reflect.ValueOf(cfg.Outputs[i]), "")
+		}
+	}
+	return changes
+}
+
+// diffFields appends a change for each differing field of two structs, named
+// by its JSON key and skipping the field named skip.
+func diffFields(changes *[]string, prefix string, a, b reflect.Value, skip string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Name == skip {
+			continue
+		}
+		av, bv := a.Field(i).Interface(), b.Field(i).Interface()
+		if av == bv {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		*changes = append(*changes, fmt.Sprintf("%s%s: %#v -> %#v", prefix, name, av, bv))
+	}
+}
+
+func describeOutput(oc OutputConfig) string {
+	if oc.Destination == "" {
+		return oc.Type
+	}
+	return oc.Type + " " + oc.Destination
+}
@@ -0,0 +1,205 @@
+package log
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, path, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+}
+
+func TestConfigWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "log.json")
+	out := filepath.Join(dir, "app.log")
+	writeConfig(t, cfgPath, `{"level":"info","flags":"none","outputs":[{"type":"file","destination":"`+out+`"}]}`)
+
+	l := New(io.Discard, "", 0)
+	w, err := l.WatchConfig(cfgPath, WatchOptions{Interval: -1})
+	require.NoError(t, err)
+	defer w.Close()
+
+	l.Info("one")
+	l.Debug("hidden")
+	require.NoError(t, w.Reload(), "unchanged config is a no-op")
+
+	writeConfig(t, cfgPath, `{"level":"debug","flags":"none","outputs":[{"type":"file","destination":"`+out+`"},{"type":"json","destination":"`+filepath.Join(dir, "app.json")+`"}]}`)
+	require.NoError(t, w.Reload())
+	l.Debug("two")
+
+	writeConfig(t, cfgPath, `{"level":"trace","outputs":[{"type":"carrier-pigeon"}]}`)
+	assert.ErrorContains(t, w.Reload(), `outputs[0].type: unknown type "carrier-pigeon"`)
+	l.Trace("hidden")
+	l.Debug("three")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := string(data)
+	assert.Contains(t, lines, "INFO     one\n", "reload appends instead of truncating")
+	assert.Contains(t, lines, `NOTICE   log: config reloaded path=`+cfgPath+` changes=level: "info" -> "debug"; outputs[1] added (json `)
+	assert.Contains(t, lines, "DEBUG    two\n")
+	assert.Contains(t, lines, `ERROR    log: config reload failed`)
+	assert.Contains(t, lines, "DEBUG    three\n")
+	assert.NotContains(t, lines, "hidden")
+}
+
+func TestConfigWatcherPolls(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "log.json")
+	out := filepath.Join(dir, "app.log")
+	writeConfig(t, cfgPath, `{"level":"info","flags":"none","outputs":[{"type":"file","destination":"`+out+`"}]}`)
+
+	l := New(io.Discard, "", 0)
+	w, err := l.WatchConfig(cfgPath, WatchOptions{Interval: 5 * time.Millisecond})
+	require.NoError(t, err)
+	defer w.Close()
+
+	writeConfig(t, cfgPath, `{"level":"notice","flags":"none","outputs":[{"type":"file","destination":"`+out+`"}]}`)
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(out)
+		return len(data) > 0
+	}, 2*time.Second, 5*time.Millisecond)
+	l.Info("dropped")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "NOTICE   log: config reloaded path="+cfgPath+" changes=level: \"info\" -> \"notice\"\n", string(data))
+}
+
+func TestWatchConfigInvalidInitial(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "log.json")
+	_, err := New(io.Discard, "", 0).WatchConfig(cfgPath, WatchOptions{})
+	assert.Error(t, err)
+}
+
+func TestConfigureReleasesFiles(t *testing.T) {
+	dir := t.TempDir()
+	openFilesMu.Lock()
+	before := len(openFiles)
+	openFilesMu.Unlock()
+
+	l := New(io.Discard, "", 0)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.Configure(Config{Outputs: []OutputConfig{
+			{Type: "file", Destination: filepath.Join(dir, "a.log")},
+			{Type: "rotating", Destination: filepath.Join(dir, "b.log")},
+		}}))
+	}
+	openFilesMu.Lock()
+	assert.Equal(t, before+2, len(openFiles))
+	openFilesMu.Unlock()
+
+	require.NoError(t, l.Configure(Config{}))
+	openFilesMu.Lock()
+	assert.Equal(t, before, len(openFiles))
+	openFilesMu.Unlock()
+}
+
+type blockingHandler struct {
+	started, release chan struct{}
+}
+
+func (h blockingHandler) Handle(Record) error {
+	close(h.started)
+	<-h.release
+	return nil
+}
+
+type closeRecorder struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *closeRecorder) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func TestConfigureWaitsForInflight(t *testing.T) {
+	h := blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	c := &closeRecorder{}
+	l := New(io.Discard, "", 0)
+	gen := &builtOutputs{outputs: []output{{h: h, min: LevelDebug}}, closers: []io.Closer{c}}
+	l.outputs, l.configured = gen.outputs, gen
+
+	go l.Info("slow")
+	<-h.started
+	done := make(chan struct{})
+	go func() {
+		_ = l.Configure(Config{})
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, c.isClosed(), "closed while a record was being handled")
+	close(h.release)
+	<-done
+	assert.True(t, c.isClosed())
+}
+
+func TestConfigWatcherReloadReachesDerivedLoggers(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "log.json")
+	out := filepath.Join(dir, "app.log")
+	writeConfig(t, cfgPath, `{"level":"info","flags":"none","outputs":[{"type":"file","destination":"`+out+`"}]}`)
+
+	l := New(io.Discard, "", 0)
+	w, err := l.WatchConfig(cfgPath, WatchOptions{Interval: -1})
+	require.NoError(t, err)
+	defer w.Close()
+
+	child := l.With("component", "db")
+	grandchild := child.With("req", 1)
+	child.Info("before reload from child")
+
+	writeConfig(t, cfgPath, `{"level":"debug","flags":"none","outputs":[{"type":"file","destination":"`+out+`"}]}`)
+	require.NoError(t, w.Reload())
+	child.Debug("after reload from child")
+	grandchild.Info("after reload from grandchild")
+	l.Info("after reload from parent")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := string(data)
+	assert.Contains(t, lines, "INFO     before reload from child component=db\n")
+	assert.Contains(t, lines, "DEBUG    after reload from child component=db\n")
+	assert.Contains(t, lines, "INFO     after reload from grandchild component=db req=1\n")
+	assert.Contains(t, lines, "INFO     after reload from parent\n")
+}
+
+func TestConfigureDoesNotHangOnBlockedHandler(t *testing.T) {
+	saved := drainTimeout
+	drainTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { drainTimeout = saved })
+
+	h := blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	c := &closeRecorder{}
+	l := New(io.Discard, "", 0)
+	gen := &builtOutputs{outputs: []output{{h: h, min: LevelDebug}}, closers: []io.Closer{c}}
+	l.outputs, l.configured = gen.outputs, gen
+
+	go l.Info("stuck")
+	<-h.started
+	require.NoError(t, l.Configure(Config{}))
+	assert.False(t, c.isClosed(), "not closed under a blocked handler")
+
+	close(h.release)
+	assert.Eventually(t, c.isClosed, time.Second, time.Millisecond)
+}
@@ -0,0 +1,34 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigWatcherSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "log.json")
+	out := filepath.Join(dir, "app.log")
+	writeConfig(t, cfgPath, `{"level":"info","flags":"none","outputs":[{"type":"file","destination":"`+out+`"}]}`)
+
+	l := New(io.Discard, "", 0)
+	w, err := l.WatchConfig(cfgPath, WatchOptions{Interval: -1, Signals: []os.Signal{syscall.SIGHUP}})
+	require.NoError(t, err)
+	defer w.Close()
+
+	writeConfig(t, cfgPath, `{"level":"debug","flags":"none","outputs":[{"type":"file","destination":"`+out+`"}]}`)
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(out)
+		return len(data) > 0
+	}, 2*time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAttachesAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	child := l.With("svc", "api")
+	child.With("req", 7).Info("hello", "k", 1)
+	l.Info("parent")
+	assert.Equal(t, "INFO     hello svc=api req=7 k=1\nINFO     parent\n", buf.String())
+
+	// outputs added to the child do not leak into the parent
+	var extra bytes.Buffer
+	child.AddWriter(LevelAll, &extra)
+	l.Info("only parent")
+	assert.Empty(t, extra.String())
+}
+
+func TestContextLoggerAndRequestID(t *testing.T) {
+	assert.Same(t, std, FromContext(context.Background()))
+
+	l := New(nil, "", 0)
+	ctx := NewContext(context.Background(), l)
+	assert.Same(t, l, FromContext(ctx))
+
+	assert.Equal(t, "", RequestIDFromContext(ctx))
+	assert.Equal(t, "abc", RequestIDFromContext(ContextWithRequestID(ctx, "abc")))
+}
+
+func TestNewRequestID(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, b := NewRequestID(), NewRequestID()
+	assert.Regexp(t, re, a)
+	assert.NotEqual(t, a, b)
+}
@@ -0,0 +1,394 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter reports whether a record matches.
+type Filter func(Record) bool
+
+// ParseFilter compiles a filter expression such as
+//
+//	level>=warn && (attr.component == "db" || msg contains "timeout")
+//
+// Fields are level, prefix, msg and attr.<key>. Operators are == != < <= > >=,
+// contains, =~ and !~ (regular expressions), combined with &&, || and ! and
+// grouped with parentheses. A bare attr.<key> tests that the attr is present.
+// Values are double- or single-quoted strings, numbers, or bare words such as
+// level names. Attrs compare numerically when both sides are numbers and as
+// text otherwise; a missing attr only satisfies !=.
+func ParseFilter(expr string) (Filter, error) {
+	toks, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{toks: toks}
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return f, nil
+}
+
+// MustParseFilter is like ParseFilter but panics on error, for expressions
+// known at compile time.
+func MustParseFilter(expr string) Filter {
+	f, err := ParseFilter(expr)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp // comparison operator
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type filterToken struct {
+	kind tokKind
+	text string // operator or identifier; unquoted value for strings
+	pos  int
+}
+
+func filterErr(pos int, format string, args ...any) error {
+	return fmt.Errorf("log: filter: %s at offset %d", fmt.Sprintf(format, args...), pos)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '.' || c == '-'
+}
+
+func lexFilter(s string) ([]filterToken, error) {
+	var toks []filterToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, filterToken{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, filterToken{tokRParen, ")", i})
+			i++
+		case strings.HasPrefix(s[i:], "&&"):
+			toks = append(toks, filterToken{tokAnd, "&&", i})
+			i += 2
+		case strings.HasPrefix(s[i:], "||"):
+			toks = append(toks, filterToken{tokOr, "||", i})
+			i += 2
+		case strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="), strings.HasPrefix(s[i:], "<="),
+			strings.HasPrefix(s[i:], ">="), strings.HasPrefix(s[i:], "=~"), strings.HasPrefix(s[i:], "!~"):
+			toks = append(toks, filterToken{tokOp, s[i : i+2], i})
+			i += 2
+		case c == '<' || c == '>':
+			toks = append(toks, filterToken{tokOp, s[i : i+1], i})
+			i++
+		case c == '!':
+			toks = append(toks, filterToken{tokNot, "!", i})
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, filterErr(i, "unterminated string")
+			}
+			lit := s[i : j+1]
+			if c == '\'' {
+				lit = `"` + strings.ReplaceAll(strings.ReplaceAll(lit[1:len(lit)-1], `\'`, `'`), `"`, `\"`) + `"`
+			}
+			v, err := strconv.Unquote(lit)
+			if err != nil {
+				return nil, filterErr(i, "invalid string %s", s[i:j+1])
+			}
+			toks = append(toks, filterToken{tokString, v, i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i + 1
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			toks = append(toks, filterToken{tokNumber, s[i:j], i})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(s) && isIdentChar(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			if word == "contains" {
+				toks = append(toks, filterToken{tokOp, word, i})
+			} else {
+				toks = append(toks, filterToken{tokIdent, word, i})
+			}
+			i = j
+		default:
+			return nil, filterErr(i, "unexpected character %q", c)
+		}
+	}
+	return append(toks, filterToken{tokEOF, "end of expression", len(s)}), nil
+}
+
+type filterParser struct {
+	toks []filterToken
+	pos  int
+}
+
+func (p *filterParser) peek() filterToken { return p.toks[p.pos] }
+
+func (p *filterParser) next() filterToken {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) errorf(t filterToken, format string, args ...any) error {
+	return filterErr(t.pos, format, args...)
+}
+
+func (p *filterParser) or() (Filter, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r Record) bool { return l(r) || right(r) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (Filter, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r Record) bool { return l(r) && right(r) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) unary() (Filter, error) {
+	switch t := p.peek(); t.kind {
+	case tokNot:
+		p.next()
+		f, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(r Record) bool { return !f(r) }, nil
+	case tokLParen:
+		p.next()
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return nil, p.errorf(c, "expected \")\", found %q", c.text)
+		}
+		return f, nil
+	case tokIdent:
+		return p.comparison()
+	default:
+		return nil, p.errorf(t, "expected a field, found %q", t.text)
+	}
+}
+
+func (p *filterParser) comparison() (Filter, error) {
+	field := p.next()
+	key, isAttr := strings.CutPrefix(field.text, "attr.")
+	if isAttr && key == "" {
+		return nil, p.errorf(field, "missing attr name")
+	}
+	if !isAttr && field.text != "level" && field.text != "prefix" && field.text != "msg" {
+		return nil, p.errorf(field, "unknown field %q (want level, prefix, msg or attr.<key>)", field.text)
+	}
+	op := p.peek()
+	if op.kind != tokOp {
+		if isAttr {
+			return func(r Record) bool { _, ok := recordAttr(r, key); return ok }, nil
+		}
+		return nil, p.errorf(op, "expected an operator after %s, found %q", field.text, op.text)
+	}
+	p.next()
+	val := p.next()
+	if val.kind != tokIdent && val.kind != tokString && val.kind != tokNumber {
+		return nil, p.errorf(val, "expected a value after %s, found %q", op.text, val.text)
+	}
+
+	if op.text == "=~" || op.text == "!~" {
+		re, err := regexp.Compile(val.text)
+		if err != nil {
+			return nil, p.errorf(val, "invalid regexp: %v", err)
+		}
+		get := fieldText(field.text, key, isAttr)
+		want := op.text == "=~"
+		return func(r Record) bool {
+			s, ok := get(r)
+			if !ok {
+				return !want
+			}
+			return re.MatchString(s) == want
+		}, nil
+	}
+
+	switch {
+	case field.text == "level":
+		if op.text == "contains" {
+			return nil, p.errorf(op, "contains does not apply to level")
+		}
+		lvl, err := ParseLevel(val.text)
+		if err != nil {
+			return nil, p.errorf(val, "unknown level %q", val.text)
+		}
+		return func(r Record) bool { return compareOrdered(int(r.Level), int(lvl), op.text) }, nil
+	case !isAttr:
+		if op.text != "==" && op.text != "!=" && op.text != "contains" {
+			return nil, p.errorf(op, "%s does not apply to %s", op.text, field.text)
+		}
+		get := fieldText(field.text, "", false)
+		return func(r Record) bool {
+			s, _ := get(r)
+			return compareText(s, val.text, op.text)
+		}, nil
+	}
+
+	num, numErr := strconv.ParseFloat(val.text, 64)
+	isNum := val.kind == tokNumber && numErr == nil
+	return func(r Record) bool {
+		v, ok := recordAttr(r, key)
+		if !ok {
+			return op.text == "!="
+		}
+		if isNum {
+			if f, ok := toFloat(v); ok && op.text != "contains" {
+				return compareOrdered(f, num, op.text)
+			}
+		}
+		return compareText(fmt.Sprint(v), val.text, op.text)
+	}, nil
+}
+
+// fieldText returns an accessor for the text of a string field or attr.
+func fieldText(field, key string, isAttr bool) func(Record) (string, bool) {
+	switch {
+	case isAttr:
+		return func(r Record) (string, bool) {
+			v, ok := recordAttr(r, key)
+			if !ok {
+				return "", false
+			}
+			return fmt.Sprint(v), true
+		}
+	case field == "prefix":
+		return func(r Record) (string, bool) { return r.Prefix, true }
+	case field == "level":
+		return func(r Record) (string, bool) { return strings.TrimSpace(r.Level.String()), true }
+	default:
+		return func(r Record) (string, bool) { return r.Message, true }
+	}
+}
+
+func recordAttr(r Record, key string) (any, bool) {
+	for i := len(r.Attrs) - 1; i >= 0; i-- {
+		if r.Attrs[i].Key == key {
+			return r.Attrs[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func compareOrdered[T int | float64 | string](a, b T, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func compareText(a, b, op string) bool {
+	if op == "contains" {
+		return strings.Contains(a, b)
+	}
+	return compareOrdered(a, b, op)
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	rec := Record{Level: LevelWarn, Prefix: "api", Message: "dial tcp: i/o timeout",
+		Attrs: []Attr{{Key: "component", Value: "db"}, {Key: "ms", Value: 250}, {Key: "ok", Value: false}, {Key: "req_header.x-id", Value: "a1"}}}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`level>=warn`, true},
+		{`level > warn`, false},
+		{`level == "WARNING"`, true},
+		{`level <= 4`, true},
+		{`level =~ "^(WARN|ERROR)$"`, true},
+		{`prefix == "api"`, true},
+		{`prefix != 'api'`, false},
+		{`msg contains "timeout"`, true},
+		{`msg =~ "^dial"`, true},
+		{`msg !~ "refused"`, true},
+		{`attr.component == "db"`, true},
+		{`attr.component == db`, true},
+		{`attr.ms > 100 && attr.ms <= 250`, true},
+		{`attr.ms >= 1000`, false},
+		{`attr.ms == "250"`, true},
+		{`attr.ok == false`, true},
+		{`attr.req_header.x-id == "a1"`, true},
+		{`attr.component`, true},
+		{`attr.missing`, false},
+		{`attr.missing == ""`, false},
+		{`attr.missing != "x"`, true},
+		{`attr.missing !~ "x"`, true},
+		{`level>=warn && attr.component == "db"`, true},
+		{`level>=error || attr.component == "db"`, true},
+		{`!(level>=error || attr.component == "cache")`, true},
+		{`level>=error || attr.component == "cache" && msg contains "timeout"`, false},
+		{`(level>=error || attr.component == "db") && !msg contains "refused"`, true},
+	}
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.expr)
+		if assert.NoError(t, err, tt.expr) {
+			assert.Equal(t, tt.want, f(rec), tt.expr)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := map[string]string{
+		``:                          `log: filter: expected a field, found "end of expression" at offset 0`,
+		`level >= loud`:             `log: filter: unknown level "loud" at offset 9`,
+		`lvl == warn`:               `log: filter: unknown field "lvl" (want level, prefix, msg or attr.<key>) at offset 0`,
+		`msg > "a"`:                 `log: filter: > does not apply to msg at offset 4`,
+		`level contains "W"`:        `log: filter: contains does not apply to level at offset 6`,
+		`attr.x == "a`:              `log: filter: unterminated string at offset 10`,
+		`(level >= warn`:            `log: filter: expected ")", found "end of expression" at offset 14`,
+		`level >= warn extra`:       `log: filter: unexpected "extra" at offset 14`,
+		`msg =~ "("`:                "log: filter: invalid regexp: error parsing regexp: missing closing ): `(` at offset 7",
+		`level`:                     `log: filter: expected an operator after level, found "end of expression" at offset 5`,
+		`attr. == 1`:                `log: filter: missing attr name at offset 0`,
+		`msg == &&`:                 `log: filter: expected a value after ==, found "&&" at offset 7`,
+		`level >= warn & msg == ""`: `log: filter: unexpected character '&' at offset 14`,
+	}
+	for expr, want := range tests {
+		_, err := ParseFilter(expr)
+		assert.EqualError(t, err, want, expr)
+	}
+	assert.Panics(t, func() { MustParseFilter("nope") })
+}
@@ -1,18 +1,227 @@
 package log
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChanMode selects what channel handlers do when the channel is full.
+type ChanMode int
+
+const (
+	ChanBlock   ChanMode = iota // wait for the consumer (default)
+	ChanDrop                    // drop the record immediately
+	ChanTimeout                 // wait up to Timeout, then drop
+)
+
+// defaultChanTimeout is used by ChanTimeout when Timeout is unset.
+const defaultChanTimeout = 100 * time.Millisecond
+
+var (
+	// ErrChanFull is returned when a record is dropped because the channel is full.
+	ErrChanFull = errors.New("log: channel full, record dropped")
+	// ErrChanClosed is returned when the channel has been closed by its owner.
+	ErrChanClosed = errors.New("log: channel closed, record dropped")
+)
+
+// ChanStats reports how many records a channel handler sent and dropped.
+type ChanStats struct {
+	Sent    uint64
+	Dropped uint64
+}
+
+// chanCounters tracks delivery for StringChanHandler and RecordChanHandler.
+type chanCounters struct {
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	closed  atomic.Bool
+}
+
+func (c *chanCounters) stats() ChanStats {
+	return ChanStats{Sent: c.sent.Load(), Dropped: c.dropped.Load()}
+}
+
+// chanSend delivers v on ch according to mode. Sending on a closed channel is
+// recovered and remembered so later calls return ErrChanClosed at once.
+func chanSend[T any](ch chan<- T, v T, mode ChanMode, timeout time.Duration, c *chanCounters) (err error) {
+	if c.closed.Load() {
+		c.dropped.Add(1)
+		return ErrChanClosed
+	}
+	defer func() {
+		if recover() != nil {
+			c.closed.Store(true)
+			c.dropped.Add(1)
+			err = ErrChanClosed
+		}
+	}()
+	switch mode {
+	case ChanDrop:
+		select {
+		case ch <- v:
+		default:
+			c.dropped.Add(1)
+			return ErrChanFull
+		}
+	case ChanTimeout:
+		if timeout <= 0 {
+			timeout = defaultChanTimeout
+		}
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		select {
+		case ch <- v:
+		case <-t.C:
+			c.dropped.Add(1)
+			return ErrChanFull
+		}
+	default:
+		ch <- v
+	}
+	c.sent.Add(1)
+	return nil
+}
 
 // StringChanHandler sends formatted log lines to a string channel.
 type StringChanHandler struct {
 	C chan<- string
+	// Mode controls behavior when C is full (default ChanBlock).
+	Mode ChanMode
+	// Timeout bounds the wait in ChanTimeout mode (default 100ms).
+	Timeout time.Duration
+
+	counters chanCounters
 }
 
 func (h *StringChanHandler) Handle(r Record) error {
-	ts := formatTimestamp(r.Time, r.Flags)
-	if ts != "" {
-		h.C <- fmt.Sprintf("%s %s %s", ts, r.Level.String(), r.Message)
-		return nil
+	var line string
+	if ts := formatTimestamp(r.Time, r.Flags); ts != "" {
+		line = fmt.Sprintf("%s %s %s", ts, r.Level.String(), r.Message)
+	} else {
+		line = fmt.Sprintf("%s %s", r.Level.String(), r.Message)
+	}
+	return chanSend(h.C, line, h.Mode, h.Timeout, &h.counters)
+}
+
+// Stats returns the number of lines sent and dropped so far.
+func (h *StringChanHandler) Stats() ChanStats { return h.counters.stats() }
+
+// RecordChanHandler sends each Record to a channel, leaving formatting to the
+// consumer. Attrs are copied so the consumer may keep them.
+type RecordChanHandler struct {
+	C chan<- Record
+	// Mode controls behavior when C is full (default ChanBlock).
+	Mode ChanMode
+	// Timeout bounds the wait in ChanTimeout mode (default 100ms).
+	Timeout time.Duration
+
+	counters chanCounters
+}
+
+func (h *RecordChanHandler) Handle(r Record) error {
+	r.Attrs = append([]Attr(nil), r.Attrs...)
+	return chanSend(h.C, r, h.Mode, h.Timeout, &h.counters)
+}
+
+// Stats returns the number of records sent and dropped so far.
+func (h *RecordChanHandler) Stats() ChanStats { return h.counters.stats() }
+
+// Broadcaster is a Handler that fans records out to any number of
+// subscribers. Each subscriber has its own buffered channel; records for a
+// subscriber whose buffer is full are dropped for that subscriber only, so a
+// stalled consumer never blocks logging.
+type Broadcaster struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	buffer int
+	closed bool
+}
+
+// NewBroadcaster returns a Broadcaster whose subscriptions buffer up to buffer
+// records each (default 64).
+func NewBroadcaster(buffer int) *Broadcaster {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	return &Broadcaster{subs: make(map[*Subscription]struct{}), buffer: buffer}
+}
+
+// Subscription receives records from a Broadcaster on C until it is closed.
+type Subscription struct {
+	C       <-chan Record
+	c       chan Record
+	filter  func(Record) bool
+	b       *Broadcaster
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// Subscribe registers a subscriber receiving the records for which filter
+// returns true (all records when filter is nil). After Close on the
+// Broadcaster, the returned subscription's channel is already closed.
+func (b *Broadcaster) Subscribe(filter func(Record) bool) *Subscription {
+	c := make(chan Record, b.buffer)
+	s := &Subscription{C: c, c: c, filter: filter, b: b}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(c)
+		return s
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Len returns the number of active subscriptions.
+func (b *Broadcaster) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *Broadcaster) Handle(r Record) error {
+	r.Attrs = append([]Attr(nil), r.Attrs...)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if s.filter != nil && !s.filter(r) {
+			continue
+		}
+		select {
+		case s.c <- r:
+			s.sent.Add(1)
+		default:
+			s.dropped.Add(1)
+		}
 	}
-	h.C <- fmt.Sprintf("%s %s", r.Level.String(), r.Message)
 	return nil
 }
+
+// Close closes every subscription; later subscriptions are closed at once.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		close(s.c)
+		delete(b.subs, s)
+	}
+}
+
+// Close unsubscribes s and closes its channel. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, ok := s.b.subs[s]; ok {
+		delete(s.b.subs, s)
+		close(s.c)
+	}
+}
+
+// Stats returns the number of records delivered to and dropped for s.
+func (s *Subscription) Stats() ChanStats {
+	return ChanStats{Sent: s.sent.Load(), Dropped: s.dropped.Load()}
+}
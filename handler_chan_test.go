@@ -0,0 +1,115 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringChanHandlerModes(t *testing.T) {
+	ch := make(chan string, 1)
+	h := &StringChanHandler{C: ch, Mode: ChanDrop}
+	assert.NoError(t, h.Handle(Record{Level: LevelInfo, Message: "a"}))
+	assert.ErrorIs(t, h.Handle(Record{Level: LevelInfo, Message: "b"}), ErrChanFull)
+	assert.Equal(t, ChanStats{Sent: 1, Dropped: 1}, h.Stats())
+	assert.Equal(t, "INFO     a", <-ch)
+
+	h = &StringChanHandler{C: ch, Mode: ChanTimeout, Timeout: 10 * time.Millisecond}
+	assert.NoError(t, h.Handle(Record{Level: LevelInfo, Message: "c"}))
+	start := time.Now()
+	assert.ErrorIs(t, h.Handle(Record{Level: LevelInfo, Message: "d"}), ErrChanFull)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	// a consumer freeing space within the timeout lets the send through
+	go func() { time.Sleep(5 * time.Millisecond); <-ch }()
+	h.Timeout = time.Second
+	assert.NoError(t, h.Handle(Record{Level: LevelInfo, Message: "e"}))
+	assert.Equal(t, "INFO     e", <-ch)
+	assert.Equal(t, ChanStats{Sent: 2, Dropped: 1}, h.Stats())
+}
+
+func TestChanHandlersRecoverFromClosedChannel(t *testing.T) {
+	ch := make(chan string, 1)
+	close(ch)
+	h := &StringChanHandler{C: ch}
+	assert.NotPanics(t, func() {
+		assert.ErrorIs(t, h.Handle(Record{Message: "x"}), ErrChanClosed)
+		assert.ErrorIs(t, h.Handle(Record{Message: "y"}), ErrChanClosed)
+	})
+	assert.Equal(t, ChanStats{Dropped: 2}, h.Stats())
+
+	l := New(io.Discard, "", 0)
+	l.AddHandler(LevelAll, h)
+	assert.NotPanics(t, func() { l.Info("still running") })
+}
+
+func TestRecordChanHandler(t *testing.T) {
+	ch := make(chan Record, 2)
+	l := New(io.Discard, "svc", 0)
+	l.AddHandler(LevelAll, &RecordChanHandler{C: ch})
+	l.Warn("disk low", "free", "2%")
+	r := <-ch
+	assert.Equal(t, LevelWarn, r.Level)
+	assert.Equal(t, "disk low", r.Message)
+	assert.Equal(t, "svc", r.Prefix)
+	assert.Equal(t, []Attr{{Key: "free", Value: "2%"}}, r.Attrs)
+}
+
+func TestBroadcaster(t *testing.T) {
+	b := NewBroadcaster(2)
+	all := b.Subscribe(nil)
+	errs := b.Subscribe(func(r Record) bool { return r.Level >= LevelError })
+	assert.Equal(t, 2, b.Len())
+
+	for _, lv := range []Level{LevelInfo, LevelError, LevelInfo} {
+		assert.NoError(t, b.Handle(Record{Level: lv, Message: lv.String()}))
+	}
+	assert.Equal(t, ChanStats{Sent: 2, Dropped: 1}, all.Stats(), "slow subscriber drops, logging continues")
+	assert.Equal(t, ChanStats{Sent: 1}, errs.Stats())
+	assert.Equal(t, LevelInfo, (<-all.C).Level)
+	assert.Equal(t, LevelError, (<-errs.C).Level)
+
+	errs.Close()
+	errs.Close()
+	_, ok := <-errs.C
+	assert.False(t, ok)
+	assert.Equal(t, 1, b.Len())
+
+	b.Close()
+	<-all.C
+	_, ok = <-all.C
+	assert.False(t, ok)
+	_, ok = <-b.Subscribe(nil).C
+	assert.False(t, ok)
+	assert.NoError(t, b.Handle(Record{Message: "after close"}))
+}
+
+func TestBroadcasterConcurrent(t *testing.T) {
+	b := NewBroadcaster(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = b.Handle(Record{Message: "m"})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			s := b.Subscribe(nil)
+			for j := 0; j < 10; j++ {
+				select {
+				case <-s.C:
+				default:
+				}
+			}
+			s.Close()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 0, b.Len())
+}
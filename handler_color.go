@@ -1,10 +1,10 @@
 package log
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/mattn/go-isatty"
 )
@@ -49,6 +49,7 @@ type ColorOptions struct {
 
 // ColoredWriterHandler writes text like WriterHandler but with ANSI coloring.
 type ColoredWriterHandler struct {
+	mu      sync.Mutex
 	w       io.Writer
 	opts    ColorOptions
 	enabled bool
@@ -122,17 +123,18 @@ func (h *ColoredWriterHandler) Handle(r Record) error {
 
 	// Message
 	if r.Message != "" {
+		msg := formatMessage(r.Message)
 		b.WriteByte(' ')
 		if h.enabled && h.opts.ColorMessage {
 			if c, ok := h.opts.Palette[r.Level]; ok {
 				b.WriteString(c)
-				b.WriteString(r.Message)
+				b.WriteString(msg)
 				b.WriteString(ansiReset)
 			} else {
-				b.WriteString(r.Message)
+				b.WriteString(msg)
 			}
 		} else {
-			b.WriteString(r.Message)
+			b.WriteString(msg)
 		}
 	}
 
@@ -144,17 +146,19 @@ func (h *ColoredWriterHandler) Handle(r Record) error {
 				b.WriteString(c)
 				b.WriteString(a.Key)
 				b.WriteByte('=')
-				b.WriteString(fmt.Sprint(a.Value))
+				b.WriteString(formatAttrValue(a.Value))
 				b.WriteString(ansiReset)
 				continue
 			}
 		}
 		b.WriteString(a.Key)
 		b.WriteByte('=')
-		b.WriteString(fmt.Sprint(a.Value))
+		b.WriteString(formatAttrValue(a.Value))
 	}
 
 	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	_, err := io.WriteString(h.w, b.String())
 	return err
 }
@@ -0,0 +1,149 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chrisjoyce911/log"
+	"github.com/chrisjoyce911/log/handlertest"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent subtest's reads.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+var (
+	ansiRE    = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	tsRE      = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(\.\d+)? `)
+	attrKeyRE = regexp.MustCompile(`(^| )[A-Za-z0-9_.]+=`)
+)
+
+// parseTextLine parses "[time] LEVEL [prefix] msg k=v ..." as written by the
+// text handlers.
+func parseTextLine(line string) map[string]any {
+	line = ansiRE.ReplaceAllString(line, "")
+	m := map[string]any{}
+	if ts := tsRE.FindString(line); ts != "" {
+		m["time"] = strings.TrimSpace(ts)
+		line = line[len(ts):]
+	}
+	level, rest, _ := strings.Cut(line, " ")
+	m["level"] = level
+	rest = strings.TrimLeft(rest, " ")
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.Index(rest, "]"); end > 0 {
+			m["prefix"] = rest[1:end]
+			rest = strings.TrimPrefix(rest[end+1:], " ")
+		}
+	}
+	locs := attrKeyRE.FindAllStringIndex(rest, -1)
+	if len(locs) == 0 {
+		m["msg"] = rest
+		return m
+	}
+	m["msg"] = rest[:locs[0][0]]
+	for i, loc := range locs {
+		end := len(rest)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		key, val, _ := strings.Cut(strings.TrimPrefix(rest[loc[0]:end], " "), "=")
+		if u, err := strconv.Unquote(val); err == nil && strings.HasPrefix(val, `"`) {
+			val = u // values with control characters are quoted
+		}
+		m[key] = val
+	}
+	return m
+}
+
+func parseTextLines(t *testing.T, s string) []map[string]any {
+	var out []map[string]any
+	sc := bufio.NewScanner(strings.NewReader(s))
+	sc.Buffer(nil, 4<<20)
+	for sc.Scan() {
+		out = append(out, parseTextLine(sc.Text()))
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestHandlerConformance_Writer(t *testing.T) {
+	var buf *syncBuffer
+	handlertest.Run(t,
+		func(*testing.T) log.Handler { buf = &syncBuffer{}; return log.NewWriterHandler(buf) },
+		func(t *testing.T) []map[string]any { return parseTextLines(t, buf.String()) })
+}
+
+func TestHandlerConformance_Colored(t *testing.T) {
+	var buf *syncBuffer
+	handlertest.Run(t,
+		func(*testing.T) log.Handler {
+			buf = &syncBuffer{}
+			return log.NewColoredWriterHandler(buf, log.ColorOptions{ColorLevel: true, ColorPrefix: true, ColorAttrs: true})
+		},
+		func(t *testing.T) []map[string]any { return parseTextLines(t, buf.String()) })
+}
+
+func TestHandlerConformance_JSON(t *testing.T) {
+	var buf *syncBuffer
+	handlertest.Run(t,
+		func(*testing.T) log.Handler { buf = &syncBuffer{}; return log.NewJSONHandler(buf) },
+		func(t *testing.T) []map[string]any {
+			var out []map[string]any
+			dec := json.NewDecoder(strings.NewReader(buf.String()))
+			for dec.More() {
+				var m map[string]any
+				if err := dec.Decode(&m); err != nil {
+					t.Fatal(err)
+				}
+				if attrs, ok := m["attrs"].(map[string]any); ok {
+					delete(m, "attrs")
+					for k, v := range attrs {
+						m[k] = v
+					}
+				}
+				out = append(out, m)
+			}
+			return out
+		})
+}
+
+func TestHandlerConformance_StringChan(t *testing.T) {
+	var ch chan string
+	handlertest.Run(t,
+		func(*testing.T) log.Handler { ch = make(chan string, 1024); return &log.StringChanHandler{C: ch} },
+		func(t *testing.T) []map[string]any {
+			var out []map[string]any
+			for {
+				select {
+				case s := <-ch:
+					out = append(out, parseTextLine(s))
+				default:
+					return out
+				}
+			}
+		},
+		handlertest.NoAttrs, handlertest.NoPrefix)
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DedupOptions configures DedupHandler.
+type DedupOptions struct {
+	// Equal reports whether two records are duplicates. The default compares
+	// level, prefix, message and attrs.
+	Equal func(a, b Record) bool
+	// Timeout flushes the "message repeated N times" record this long after the
+	// first record of a run, even while duplicates keep arriving; the next
+	// duplicate then starts a new run. Zero waits for the next different record
+	// or Flush.
+	Timeout time.Duration
+}
+
+// DedupHandler collapses consecutive duplicate records into the first record
+// followed by a single "message repeated N times" record, like syslog.
+// Records are forwarded to next without holding the handler's lock.
+type DedupHandler struct {
+	next Handler
+	opts DedupOptions
+
+	mu      sync.Mutex
+	last    Record
+	have    bool
+	first   time.Time // when the current run started
+	repeats int
+	timer   *time.Timer
+	gen     int // invalidates timers that fired after their run ended
+}
+
+// NewDedupHandler returns a DedupHandler forwarding to next.
+func NewDedupHandler(next Handler, opts DedupOptions) *DedupHandler {
+	if opts.Equal == nil {
+		opts.Equal = sameRecord
+	}
+	return &DedupHandler{next: next, opts: opts}
+}
+
+func (h *DedupHandler) Handle(r Record) error {
+	h.mu.Lock()
+	if h.have && h.opts.Equal(h.last, r) {
+		h.repeats++
+		h.last.Time = r.Time
+		if h.repeats == 1 {
+			h.armLocked()
+		}
+		h.mu.Unlock()
+		return nil
+	}
+	summary, ok := h.takeLocked()
+	h.last, h.have, h.first = r, true, time.Now()
+	h.mu.Unlock()
+	if ok {
+		_ = h.next.Handle(summary)
+	}
+	return h.next.Handle(r)
+}
+
+// Flush emits the pending "message repeated N times" record, if any.
+func (h *DedupHandler) Flush() error {
+	h.mu.Lock()
+	summary, ok := h.takeLocked()
+	h.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return h.next.Handle(summary)
+}
+
+// armLocked starts the timer ending the current run Timeout after its first
+// record. h.mu must be held.
+func (h *DedupHandler) armLocked() {
+	if h.opts.Timeout <= 0 {
+		return
+	}
+	h.gen++
+	gen := h.gen
+	h.timer = time.AfterFunc(h.opts.Timeout-time.Since(h.first), func() {
+		h.mu.Lock()
+		if gen != h.gen {
+			h.mu.Unlock()
+			return
+		}
+		summary, ok := h.takeLocked()
+		// A record arriving after the timeout starts a new run.
+		h.have = false
+		h.mu.Unlock()
+		if ok {
+			_ = h.next.Handle(summary)
+		}
+	})
+}
+
+// takeLocked ends the current run's repeat count and returns its summary
+// record, if there were repeats. h.mu must be held.
+func (h *DedupHandler) takeLocked() (Record, bool) {
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+		h.gen++
+	}
+	if h.repeats == 0 {
+		return Record{}, false
+	}
+	n := h.repeats
+	h.repeats = 0
+	return Record{
+		Time:    h.last.Time,
+		Level:   h.last.Level,
+		Message: "message repeated " + strconv.Itoa(n) + " times",
+		Prefix:  h.last.Prefix,
+		Flags:   h.last.Flags,
+	}, true
+}
+
+func sameRecord(a, b Record) bool {
+	if a.Level != b.Level || a.Prefix != b.Prefix || a.Message != b.Message || len(a.Attrs) != len(b.Attrs) {
+		return false
+	}
+	for i := range a.Attrs {
+		if a.Attrs[i].Key != b.Attrs[i].Key || fmt.Sprint(a.Attrs[i].Value) != fmt.Sprint(b.Attrs[i].Value) {
+			return false
+		}
+	}
+	return true
+}
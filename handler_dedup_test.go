@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupHandler_CollapsesConsecutive(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewDedupHandler(&WriterHandler{w: &buf}, DedupOptions{})
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, h.Handle(Record{Level: LevelError, Message: "dial tcp: refused", Attrs: []Attr{{Key: "host", Value: "db"}}}))
+	}
+	assert.NoError(t, h.Handle(Record{Level: LevelInfo, Message: "connected"}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		"ERROR    dial tcp: refused host=db",
+		"ERROR    message repeated 3 times",
+		"INFO     connected",
+	}, lines)
+}
+
+func TestDedupHandler_AttrsDifferAndCustomEqual(t *testing.T) {
+	c := &captureHandler{}
+	h := NewDedupHandler(c, DedupOptions{})
+	_ = h.Handle(Record{Message: "m", Attrs: []Attr{{Key: "n", Value: 1}}})
+	_ = h.Handle(Record{Message: "m", Attrs: []Attr{{Key: "n", Value: 2}}})
+	assert.Equal(t, []string{"m", "m"}, c.messages())
+
+	c2 := &captureHandler{}
+	h2 := NewDedupHandler(c2, DedupOptions{Equal: func(a, b Record) bool { return a.Message == b.Message }})
+	_ = h2.Handle(Record{Message: "m", Attrs: []Attr{{Key: "n", Value: 1}}})
+	_ = h2.Handle(Record{Message: "m", Attrs: []Attr{{Key: "n", Value: 2}}})
+	assert.NoError(t, h2.Flush())
+	assert.Equal(t, []string{"m", "message repeated 1 times"}, c2.messages())
+}
+
+func TestDedupHandler_Timeout(t *testing.T) {
+	c := &captureHandler{}
+	h := NewDedupHandler(c, DedupOptions{Timeout: 10 * time.Millisecond})
+	_ = h.Handle(Record{Message: "retry"})
+	_ = h.Handle(Record{Message: "retry"})
+	_ = h.Handle(Record{Message: "retry"})
+	assert.Eventually(t, func() bool { return len(c.messages()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "message repeated 2 times", c.messages()[1])
+
+	// after the timeout the same message starts a new run
+	_ = h.Handle(Record{Message: "retry"})
+	assert.Equal(t, []string{"retry", "message repeated 2 times", "retry"}, c.messages())
+}
+
+func TestDedupHandler_TimeoutFromFirstOccurrence(t *testing.T) {
+	c := &captureHandler{}
+	h := NewDedupHandler(c, DedupOptions{Timeout: 20 * time.Millisecond})
+	stop := time.After(100 * time.Millisecond)
+	for done := false; !done; {
+		select {
+		case <-stop:
+			done = true
+		default:
+			_ = h.Handle(Record{Message: "retry"})
+			time.Sleep(time.Millisecond)
+		}
+	}
+	var summaries int
+	for _, m := range c.messages() {
+		if strings.HasPrefix(m, "message repeated") {
+			summaries++
+		}
+	}
+	assert.GreaterOrEqual(t, summaries, 2, "a run repeated faster than the timeout is still summarized")
+}
+
+// relogHandler logs through the dedup handler it wraps, as a sink reporting
+// its own failures might.
+type relogHandler struct {
+	h    *DedupHandler
+	seen []string
+}
+
+func (r *relogHandler) Handle(rec Record) error {
+	r.seen = append(r.seen, rec.Message)
+	if rec.Message == "write failed" {
+		return r.h.Handle(Record{Message: "sink error"})
+	}
+	return nil
+}
+
+func TestDedupHandler_NextMayLog(t *testing.T) {
+	next := &relogHandler{}
+	h := NewDedupHandler(next, DedupOptions{})
+	next.h = h
+	done := make(chan struct{})
+	go func() {
+		_ = h.Handle(Record{Message: "write failed"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Handle deadlocked")
+	}
+	assert.Equal(t, []string{"write failed", "sink error"}, next.seen)
+}
@@ -0,0 +1,70 @@
+package log
+
+import "errors"
+
+// FilterHandler forwards only the records matching a Filter.
+type FilterHandler struct {
+	next   Handler
+	filter Filter
+}
+
+// NewFilterHandler returns a handler passing records matching f to next.
+func NewFilterHandler(next Handler, f Filter) *FilterHandler {
+	return &FilterHandler{next: next, filter: f}
+}
+
+func (h *FilterHandler) Handle(r Record) error {
+	if h.filter != nil && !h.filter(r) {
+		return nil
+	}
+	return h.next.Handle(r)
+}
+
+// Route pairs a Filter with the Handler that receives matching records.
+// A nil Filter matches every record.
+type Route struct {
+	Filter  Filter
+	Handler Handler
+}
+
+// RouterMode selects how many routes a Router delivers a record to.
+type RouterMode int
+
+const (
+	RouterFirst RouterMode = iota // only the first matching route
+	RouterAll                     // every matching route
+)
+
+// Router is a Handler that sends each record to matching routes, in order.
+// Records matching no route go to Fallback when it is set.
+type Router struct {
+	Mode     RouterMode
+	Routes   []Route
+	Fallback Handler
+}
+
+// NewRouter returns a Router with the given mode and routes.
+func NewRouter(mode RouterMode, routes ...Route) *Router {
+	return &Router{Mode: mode, Routes: routes}
+}
+
+func (rt *Router) Handle(r Record) error {
+	var errs []error
+	matched := false
+	for _, route := range rt.Routes {
+		if route.Filter != nil && !route.Filter(r) {
+			continue
+		}
+		matched = true
+		if err := route.Handler.Handle(r); err != nil {
+			errs = append(errs, err)
+		}
+		if rt.Mode == RouterFirst {
+			break
+		}
+	}
+	if !matched && rt.Fallback != nil {
+		return rt.Fallback.Handle(r)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type errHandler struct{ err error }
+
+func (h errHandler) Handle(Record) error { return h.err }
+
+func TestFilterHandler(t *testing.T) {
+	c := &captureHandler{}
+	h := NewFilterHandler(c, MustParseFilter(`attr.component == "db" || level >= error`))
+	_ = h.Handle(Record{Level: LevelInfo, Message: "a", Attrs: []Attr{{Key: "component", Value: "db"}}})
+	_ = h.Handle(Record{Level: LevelInfo, Message: "b"})
+	_ = h.Handle(Record{Level: LevelError, Message: "c"})
+	assert.Equal(t, []string{"a", "c"}, c.messages())
+}
+
+func TestRouter(t *testing.T) {
+	db, errs, rest := &captureHandler{}, &captureHandler{}, &captureHandler{}
+	routes := []Route{
+		{Filter: MustParseFilter(`attr.component == "db"`), Handler: db},
+		{Filter: MustParseFilter(`level >= error`), Handler: errs},
+	}
+	recs := []Record{
+		{Level: LevelError, Message: "db down", Attrs: []Attr{{Key: "component", Value: "db"}}},
+		{Level: LevelError, Message: "api down"},
+		{Level: LevelInfo, Message: "hello"},
+	}
+
+	r := NewRouter(RouterFirst, routes...)
+	r.Fallback = rest
+	for _, rec := range recs {
+		assert.NoError(t, r.Handle(rec))
+	}
+	assert.Equal(t, []string{"db down"}, db.messages())
+	assert.Equal(t, []string{"api down"}, errs.messages())
+	assert.Equal(t, []string{"hello"}, rest.messages())
+
+	db, errs = &captureHandler{}, &captureHandler{}
+	routes[0].Handler, routes[1].Handler = db, errs
+	r = NewRouter(RouterAll, routes...)
+	for _, rec := range recs {
+		assert.NoError(t, r.Handle(rec))
+	}
+	assert.Equal(t, []string{"db down"}, db.messages())
+	assert.Equal(t, []string{"db down", "api down"}, errs.messages())
+
+	boom := errors.New("boom")
+	r = NewRouter(RouterAll, Route{Handler: errHandler{boom}}, Route{Handler: errHandler{boom}})
+	assert.ErrorIs(t, r.Handle(Record{}), boom)
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LogfmtHandler writes records as logfmt lines:
+//
+//	time="2024/03/05 14:07:09" level=warn prefix=api msg="disk low" free=2%
+type LogfmtHandler struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogfmtHandler creates a LogfmtHandler writing to w (defaults to stderr if nil).
+func NewLogfmtHandler(w io.Writer) *LogfmtHandler {
+	if w == nil {
+		w = os.Stderr
+	}
+	return &LogfmtHandler{w: w}
+}
+
+func (h *LogfmtHandler) Handle(r Record) error {
+	b := &strings.Builder{}
+	if ts := formatTimestamp(r.Time, r.Flags); ts != "" {
+		writeLogfmt(b, "time", ts)
+		b.WriteByte(' ')
+	}
+	writeLogfmt(b, "level", strings.ToLower(strings.TrimSpace(r.Level.String())))
+	if r.Prefix != "" {
+		b.WriteByte(' ')
+		writeLogfmt(b, "prefix", r.Prefix)
+	}
+	b.WriteByte(' ')
+	writeLogfmt(b, "msg", r.Message)
+	for _, a := range r.Attrs {
+		b.WriteByte(' ')
+		writeLogfmt(b, a.Key, fmt.Sprint(a.Value))
+	}
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// writeLogfmt writes key=value, quoting the value when it is empty or contains
+// spaces, quotes, '=' or control characters.
+func writeLogfmt(b *strings.Builder, key, val string) {
+	b.WriteString(key)
+	b.WriteByte('=')
+	if val == "" || strings.ContainsFunc(val, func(r rune) bool {
+		return r == ' ' || r == '"' || r == '=' || unicode.IsControl(r)
+	}) {
+		b.WriteString(strconv.Quote(val))
+		return
+	}
+	b.WriteString(val)
+}
@@ -0,0 +1,22 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewLogfmtHandler(&buf)
+	ts := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	_ = h.Handle(Record{Time: ts, Flags: LstdFlags | LUTC, Level: LevelWarn, Prefix: "api", Message: "disk low",
+		Attrs: []Attr{{Key: "free", Value: "2%"}, {Key: "path", Value: ""}, {Key: "q", Value: `a="b"`}}})
+	_ = h.Handle(Record{Level: LevelInfo, Message: "line\nbreak"})
+	assert.Equal(t,
+		"time=\"2024/03/05 14:07:09\" level=warn prefix=api msg=\"disk low\" free=2% path=\"\" q=\"a=\\\"b\\\"\"\n"+
+			"level=info msg=\"line\\nbreak\"\n",
+		buf.String())
+}
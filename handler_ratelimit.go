@@ -0,0 +1,168 @@
+package log
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimitOptions configures RateLimitHandler.
+type RateLimitOptions struct {
+	// Rate is the sustained number of records per second allowed through.
+	Rate float64
+	// Burst is the bucket size, i.e. how many records may pass at once (default 1).
+	Burst int
+	// KeyAttr, when set, gives each distinct value of that attr its own bucket so
+	// one noisy key (for example "tenant") cannot starve the others. Records
+	// without the attr share a single bucket. Only the 1024 most recently seen
+	// keys are tracked; records dropped for an evicted key are reported when
+	// it is evicted.
+	KeyAttr string
+	// OverflowLevel is the level of the synthetic record reporting dropped records
+	// (zero value: LevelInfo).
+	OverflowLevel Level
+}
+
+// RateLimitStats reports how many records a RateLimitHandler allowed and dropped.
+type RateLimitStats struct {
+	Allowed uint64
+	Dropped uint64
+}
+
+// maxBuckets is the number of keyed buckets kept; beyond it the least recently
+// used bucket is evicted.
+const maxBuckets = 1024
+
+type tokenBucket struct {
+	key     string
+	tokens  float64
+	last    time.Time
+	dropped uint64
+}
+
+// RateLimitHandler wraps a Handler with a token-bucket rate limit, optionally
+// keyed by an attr value. Records over the limit are dropped and counted; when
+// a bucket recovers a single synthetic record reports how many were dropped.
+type RateLimitHandler struct {
+	next Handler
+	opts RateLimitOptions
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element // of *tokenBucket
+	lru     *list.List               // most recently used first
+
+	allowed atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// NewRateLimitHandler returns a RateLimitHandler forwarding allowed records to next.
+func NewRateLimitHandler(next Handler, opts RateLimitOptions) *RateLimitHandler {
+	if opts.Burst <= 0 {
+		opts.Burst = 1
+	}
+	return &RateLimitHandler{
+		next:    next,
+		opts:    opts,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (h *RateLimitHandler) Handle(r Record) error {
+	now := r.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	key := h.key(r)
+
+	h.mu.Lock()
+	b, evicted := h.bucketLocked(key, now)
+	h.refill(b, now)
+	if b.tokens < 1 {
+		b.dropped++
+		h.mu.Unlock()
+		h.dropped.Add(1)
+		h.reportOverflow(r, now, evicted)
+		return nil
+	}
+	b.tokens--
+	overflow := b.dropped
+	b.dropped = 0
+	h.mu.Unlock()
+
+	h.allowed.Add(1)
+	h.reportOverflow(r, now, evicted)
+	if overflow > 0 {
+		h.reportOverflow(r, now, &tokenBucket{key: key, dropped: overflow})
+	}
+	return h.next.Handle(r)
+}
+
+// reportOverflow sends the synthetic record for b's dropped records, if any,
+// using r's prefix and flags.
+func (h *RateLimitHandler) reportOverflow(r Record, now time.Time, b *tokenBucket) {
+	if b == nil || b.dropped == 0 {
+		return
+	}
+	attrs := []Attr{{Key: "dropped", Value: b.dropped}}
+	if h.opts.KeyAttr != "" && b.key != "" {
+		attrs = append(attrs, Attr{Key: h.opts.KeyAttr, Value: b.key})
+	}
+	_ = h.next.Handle(Record{
+		Time:    now,
+		Level:   h.opts.OverflowLevel,
+		Message: "rate limit exceeded: dropped " + strconv.FormatUint(b.dropped, 10) + " records",
+		Prefix:  r.Prefix,
+		Attrs:   attrs,
+		Flags:   r.Flags,
+	})
+}
+
+func (h *RateLimitHandler) key(r Record) string {
+	if h.opts.KeyAttr == "" {
+		return ""
+	}
+	for _, a := range r.Attrs {
+		if a.Key == h.opts.KeyAttr {
+			return fmt.Sprint(a.Value)
+		}
+	}
+	return ""
+}
+
+func (h *RateLimitHandler) refill(b *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * h.opts.Rate
+		if max := float64(h.opts.Burst); b.tokens > max {
+			b.tokens = max
+		}
+		b.last = now
+	}
+}
+
+// bucketLocked returns the bucket for key, creating it and evicting the least
+// recently used bucket when maxBuckets are in use. The evicted bucket is
+// returned so its dropped records can still be reported. h.mu must be held.
+func (h *RateLimitHandler) bucketLocked(key string, now time.Time) (b, evicted *tokenBucket) {
+	if e, ok := h.buckets[key]; ok {
+		h.lru.MoveToFront(e)
+		return e.Value.(*tokenBucket), nil
+	}
+	if h.lru.Len() >= maxBuckets {
+		oldest := h.lru.Back()
+		h.lru.Remove(oldest)
+		evicted = oldest.Value.(*tokenBucket)
+		delete(h.buckets, evicted.key)
+	}
+	b = &tokenBucket{key: key, tokens: float64(h.opts.Burst), last: now}
+	h.buckets[key] = h.lru.PushFront(b)
+	return b, evicted
+}
+
+// Stats returns the number of records allowed and dropped so far.
+func (h *RateLimitHandler) Stats() RateLimitStats {
+	return RateLimitStats{Allowed: h.allowed.Load(), Dropped: h.dropped.Load()}
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitHandler_BurstAndRecovery(t *testing.T) {
+	c := &captureHandler{}
+	h := NewRateLimitHandler(c, RateLimitOptions{Rate: 1, Burst: 2, OverflowLevel: LevelWarn})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, h.Handle(Record{Time: base, Level: LevelAlert, Message: "page"}))
+	}
+	assert.Len(t, c.records, 2)
+	assert.Equal(t, RateLimitStats{Allowed: 2, Dropped: 3}, h.Stats())
+
+	// one token refills after a second; overflow is reported before the record
+	assert.NoError(t, h.Handle(Record{Time: base.Add(time.Second), Level: LevelAlert, Message: "page"}))
+	assert.Equal(t, []string{"page", "page", "rate limit exceeded: dropped 3 records", "page"}, c.messages())
+	assert.Equal(t, LevelWarn, c.records[2].Level)
+	assert.Equal(t, uint64(3), c.records[2].Attrs[0].Value)
+}
+
+func TestRateLimitHandler_KeyedBuckets(t *testing.T) {
+	c := &captureHandler{}
+	h := NewRateLimitHandler(c, RateLimitOptions{Rate: 1, KeyAttr: "tenant"})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_ = h.Handle(Record{Time: base, Message: "noisy", Attrs: []Attr{{Key: "tenant", Value: "a"}}})
+	}
+	_ = h.Handle(Record{Time: base, Message: "quiet", Attrs: []Attr{{Key: "tenant", Value: "b"}}})
+	_ = h.Handle(Record{Time: base, Message: "none"})
+	assert.Equal(t, []string{"noisy", "quiet", "none"}, c.messages())
+
+	_ = h.Handle(Record{Time: base.Add(time.Second), Message: "noisy", Attrs: []Attr{{Key: "tenant", Value: "a"}}})
+	over := c.records[3]
+	assert.Equal(t, "rate limit exceeded: dropped 2 records", over.Message)
+	assert.Contains(t, over.Attrs, Attr{Key: "tenant", Value: "a"})
+}
+
+func TestRateLimitHandler_EvictsLeastRecentlyUsedBucket(t *testing.T) {
+	c := &captureHandler{}
+	h := NewRateLimitHandler(c, RateLimitOptions{Rate: 1, KeyAttr: "k"})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rec := func(k any) Record { return Record{Time: base, Message: "m", Attrs: []Attr{{Key: "k", Value: k}}} }
+	for i := 0; i < maxBuckets; i++ {
+		_ = h.Handle(rec(i))
+	}
+	_ = h.Handle(rec(0)) // dropped, and 0 becomes the most recently used
+	_ = h.Handle(rec("new"))
+	assert.Len(t, h.buckets, maxBuckets)
+	assert.Contains(t, h.buckets, "0")
+	assert.NotContains(t, h.buckets, "1")
+
+	// key 1 was evicted, so it starts again with a full bucket
+	n := len(c.records)
+	_ = h.Handle(rec(1))
+	assert.Len(t, c.records, n+1)
+	_ = h.Handle(rec(0))
+	assert.Len(t, c.records, n+1, "key 0 is still limited")
+}
+
+func TestRateLimitHandler_ReportsDropsOfEvictedBucket(t *testing.T) {
+	c := &captureHandler{}
+	h := NewRateLimitHandler(c, RateLimitOptions{Rate: 1, KeyAttr: "k"})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rec := func(k any) Record { return Record{Time: base, Message: "m", Attrs: []Attr{{Key: "k", Value: k}}} }
+	for i := 0; i < 4; i++ {
+		_ = h.Handle(rec("hot")) // one allowed, three dropped
+	}
+	for i := 0; i < maxBuckets; i++ {
+		_ = h.Handle(rec(i))
+	}
+	assert.NotContains(t, h.buckets, "hot")
+	var over []Record
+	for _, r := range c.records {
+		if r.Message != "m" {
+			over = append(over, r)
+		}
+	}
+	if assert.Len(t, over, 1) {
+		assert.Equal(t, "rate limit exceeded: dropped 3 records", over[0].Message)
+		assert.Contains(t, over[0].Attrs, Attr{Key: "k", Value: "hot"})
+	}
+}
@@ -0,0 +1,191 @@
+package log
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// maxRingKeys is the number of per-key buffers a keyed RingBufferHandler
+// keeps; beyond it the least recently used buffer is discarded.
+const maxRingKeys = 1024
+
+// RingBufferHandler keeps the most recent records in memory at all levels.
+// When a record at or above the trigger level arrives, the buffered records not
+// yet dumped are flushed to the target handler ahead of the trigger record, so
+// DEBUG context is only written when something goes wrong.
+//
+// Attach it at LevelAll so that diagnostic records reach the buffer:
+//
+//	rb := log.NewRingBufferHandler(log.NewJSONHandler(f), 500, log.LevelError)
+//	log.AddHandler(log.LevelAll, rb)
+//
+// With WithRingKey the records are buffered per value of an attr such as
+// "request_id", so an error in one request dumps that request's records only.
+//
+// RingBufferHandler also implements http.Handler, serving the buffered records
+// as text (or JSON with ?format=json), optionally filtered with ?level=warn.
+type RingBufferHandler struct {
+	target  Handler
+	trigger Level
+	keyAttr string
+
+	mu   sync.Mutex
+	all  *ring                    // every record, for Records and ServeHTTP
+	keys map[string]*list.Element // of *ring, when keyAttr is set
+	lru  *list.List               // most recently used first
+}
+
+// RingOption configures a RingBufferHandler.
+type RingOption func(*RingBufferHandler)
+
+// WithRingKey buffers records per value of the attr key (e.g. "request_id"),
+// each in its own buffer of the handler's size, and dumps only the trigger
+// record's buffer. Records without the attr share one buffer. The 1024 most
+// recently used keys are kept.
+func WithRingKey(key string) RingOption {
+	return func(h *RingBufferHandler) { h.keyAttr = key }
+}
+
+// NewRingBufferHandler returns a RingBufferHandler holding the last size records
+// (default 256 when size <= 0) and dumping them to target on records >= trigger.
+func NewRingBufferHandler(target Handler, size int, trigger Level, opts ...RingOption) *RingBufferHandler {
+	if size <= 0 {
+		size = 256
+	}
+	h := &RingBufferHandler{target: target, trigger: trigger, all: newRing(size)}
+	for _, o := range opts {
+		o(h)
+	}
+	if h.keyAttr != "" {
+		h.keys = make(map[string]*list.Element)
+		h.lru = list.New()
+	}
+	return h
+}
+
+func (h *RingBufferHandler) Handle(r Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dump := h.all
+	if h.keyAttr != "" {
+		h.all.add(r)
+		dump = h.ringLocked(h.key(r))
+	}
+	var pending []Record
+	if r.Level >= h.trigger {
+		pending = dump.since(dump.dumped)
+	}
+	dump.add(r)
+	if r.Level < h.trigger {
+		return nil
+	}
+	dump.dumped = dump.seq
+	for _, p := range pending {
+		_ = h.target.Handle(p)
+	}
+	return h.target.Handle(r)
+}
+
+func (h *RingBufferHandler) key(r Record) string {
+	for _, a := range r.Attrs {
+		if a.Key == h.keyAttr {
+			return fmt.Sprint(a.Value)
+		}
+	}
+	return ""
+}
+
+// ringLocked returns the buffer for key, creating it and discarding the least
+// recently used buffer when maxRingKeys are in use. h.mu must be held.
+func (h *RingBufferHandler) ringLocked(key string) *ring {
+	if e, ok := h.keys[key]; ok {
+		h.lru.MoveToFront(e)
+		return e.Value.(*ring)
+	}
+	if h.lru.Len() >= maxRingKeys {
+		oldest := h.lru.Back()
+		h.lru.Remove(oldest)
+		delete(h.keys, oldest.Value.(*ring).key)
+	}
+	rg := newRing(len(h.all.buf))
+	rg.key = key
+	h.keys[key] = h.lru.PushFront(rg)
+	return rg
+}
+
+// Records returns a snapshot of the buffered records, oldest first. A keyed
+// handler returns the most recent records of all keys.
+func (h *RingBufferHandler) Records() []Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.all.since(0)
+}
+
+// ring is a fixed-size buffer of the most recent records.
+type ring struct {
+	key    string
+	buf    []Record
+	next   int    // index of the next write
+	full   bool   // buffer has wrapped
+	seq    uint64 // records added so far
+	dumped uint64 // seq of the last record flushed to target
+}
+
+func newRing(size int) *ring { return &ring{buf: make([]Record, size)} }
+
+func (rg *ring) add(r Record) {
+	rg.buf[rg.next] = r
+	rg.next = (rg.next + 1) % len(rg.buf)
+	if rg.next == 0 {
+		rg.full = true
+	}
+	rg.seq++
+}
+
+// since returns buffered records with a sequence number above seq, oldest
+// first.
+func (rg *ring) since(seq uint64) []Record {
+	n := rg.next
+	if rg.full {
+		n = len(rg.buf)
+	}
+	if avail := rg.seq - seq; avail < uint64(n) {
+		n = int(avail)
+	}
+	out := make([]Record, 0, n)
+	for i := n; i > 0; i-- {
+		out = append(out, rg.buf[(rg.next-i+len(rg.buf))%len(rg.buf)])
+	}
+	return out
+}
+
+// ServeHTTP writes the buffered records. Query parameters: level (minimum level,
+// e.g. "warn") and format ("text" default, or "json").
+func (h *RingBufferHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	min := LevelAll
+	if s := r.URL.Query().Get("level"); s != "" {
+		lvl, err := ParseLevel(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		min = lvl
+	}
+	var out Handler
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		out = NewJSONHandler(w)
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		out = &WriterHandler{w: w}
+	}
+	for _, rec := range h.Records() {
+		if rec.Level >= min {
+			if err := out.Handle(rec); err != nil {
+				return
+			}
+		}
+	}
+}
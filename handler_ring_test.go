@@ -0,0 +1,91 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingBufferHandler_DumpOnTrigger(t *testing.T) {
+	c := &captureHandler{}
+	h := NewRingBufferHandler(c, 3, LevelError)
+	for _, m := range []string{"d1", "d2", "d3", "d4"} {
+		assert.NoError(t, h.Handle(Record{Level: LevelDebug, Message: m}))
+	}
+	assert.Empty(t, c.records)
+
+	assert.NoError(t, h.Handle(Record{Level: LevelError, Message: "boom"}))
+	// only the last 3 records are kept
+	assert.Equal(t, []string{"d2", "d3", "d4", "boom"}, c.messages())
+
+	// a second trigger only dumps records since the previous dump
+	_ = h.Handle(Record{Level: LevelDebug, Message: "d5"})
+	_ = h.Handle(Record{Level: LevelCritical, Message: "boom2"})
+	assert.Equal(t, []string{"d2", "d3", "d4", "boom", "d5", "boom2"}, c.messages())
+
+	_ = h.Handle(Record{Level: LevelError, Message: "boom3"})
+	assert.Equal(t, "boom3", c.messages()[6])
+	assert.Len(t, c.records, 7)
+}
+
+func TestRingBufferHandler_PerKey(t *testing.T) {
+	c := &captureHandler{}
+	h := NewRingBufferHandler(c, 3, LevelError, WithRingKey("request_id"))
+	rec := func(lvl Level, msg, id string) Record {
+		return Record{Level: lvl, Message: msg, Attrs: []Attr{{Key: "request_id", Value: id}}}
+	}
+	_ = h.Handle(rec(LevelDebug, "a1", "A"))
+	_ = h.Handle(rec(LevelDebug, "b1", "B"))
+	_ = h.Handle(rec(LevelDebug, "a2", "A"))
+	_ = h.Handle(rec(LevelDebug, "b2", "B"))
+	_ = h.Handle(Record{Level: LevelDebug, Message: "none"})
+	_ = h.Handle(rec(LevelError, "a failed", "A"))
+	assert.Equal(t, []string{"a1", "a2", "a failed"}, c.messages())
+
+	_ = h.Handle(rec(LevelError, "b failed", "B"))
+	assert.Equal(t, []string{"a1", "a2", "a failed", "b1", "b2", "b failed"}, c.messages())
+	assert.Len(t, h.Records(), 3, "the shared view keeps the most recent records of all keys")
+
+	for i := 0; i < maxRingKeys; i++ {
+		_ = h.Handle(rec(LevelDebug, "x", fmt.Sprint(i)))
+	}
+	assert.Len(t, h.keys, maxRingKeys)
+}
+
+func TestRingBufferHandler_Records(t *testing.T) {
+	h := NewRingBufferHandler(&captureHandler{}, 0, LevelError)
+	assert.Empty(t, h.Records())
+	_ = h.Handle(Record{Level: LevelInfo, Message: "a"})
+	_ = h.Handle(Record{Level: LevelError, Message: "b"})
+	recs := h.Records()
+	assert.Len(t, recs, 2)
+	assert.Equal(t, "a", recs[0].Message)
+	assert.Equal(t, "b", recs[1].Message)
+}
+
+func TestRingBufferHandler_ServeHTTP(t *testing.T) {
+	h := NewRingBufferHandler(&captureHandler{}, 10, LevelError)
+	l := New(nil, "", 0)
+	l.SetOutput(&bytes.Buffer{})
+	l.AddHandler(LevelAll, h)
+	l.Debug("dbg", "k", 1)
+	l.Warn("careful")
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/logs", nil))
+	assert.Equal(t, "DEBUG    dbg k=1\nWARN     careful\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/logs?level=warn&format=json", nil))
+	assert.Equal(t, 1, strings.Count(rr.Body.String(), "\n"))
+	assert.Contains(t, rr.Body.String(), `"msg":"careful"`)
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/logs?level=loud", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,176 @@
+package log
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingOptions configures SamplingHandler.
+//
+// Counting sampling (First/Thereafter) is applied per (level, message) key within
+// each Window. Probabilistic sampling (Rates) is applied per level afterwards.
+type SamplingOptions struct {
+	// First is the number of records per key passed through in each window before
+	// sampling starts. Zero disables counting sampling.
+	First int
+	// Thereafter passes every Mth record per key once First is exceeded.
+	// Zero drops every record past First until the window resets.
+	Thereafter int
+	// Window is the period after which per-key counters reset (default 1s).
+	Window time.Duration
+	// Rates maps a level to the probability (0..1) that a record at that level is kept.
+	// Levels not present are always kept.
+	Rates map[Level]float64
+	// SummaryInterval controls how often a summary record reporting suppressed
+	// records is emitted. Summaries are emitted with the next handled record once
+	// the interval has elapsed, or on Flush. Zero disables summaries.
+	SummaryInterval time.Duration
+	// SummaryLevel is the level of summary records (zero value: LevelInfo).
+	SummaryLevel Level
+}
+
+// SamplingStats reports how many records a SamplingHandler forwarded and dropped.
+type SamplingStats struct {
+	Sampled uint64
+	Dropped uint64
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+// SamplingHandler wraps a Handler and drops records to keep repetitive or
+// high-volume logging under control.
+type SamplingHandler struct {
+	next Handler
+	opts SamplingOptions
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[sampleKey]int
+	lastSummary time.Time
+	suppressed  uint64 // dropped since last summary
+	last        Record // template for summary records
+	rnd         func() float64
+
+	sampled atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// NewSamplingHandler returns a SamplingHandler forwarding kept records to next.
+func NewSamplingHandler(next Handler, opts SamplingOptions) *SamplingHandler {
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+	return &SamplingHandler{
+		next:   next,
+		opts:   opts,
+		counts: make(map[sampleKey]int),
+		rnd:    rand.Float64,
+	}
+}
+
+func (h *SamplingHandler) Handle(r Record) error {
+	now := r.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	h.mu.Lock()
+	summary, emit := h.summaryLocked(now)
+	keep := h.keepLocked(r, now)
+	if !keep {
+		h.suppressed++
+		h.last = r
+	}
+	h.mu.Unlock()
+
+	if emit {
+		_ = h.next.Handle(summary)
+	}
+	if !keep {
+		h.dropped.Add(1)
+		return nil
+	}
+	h.sampled.Add(1)
+	return h.next.Handle(r)
+}
+
+// keepLocked decides whether r passes the sampling policies. h.mu must be held.
+func (h *SamplingHandler) keepLocked(r Record, now time.Time) bool {
+	if h.opts.First > 0 {
+		if h.windowStart.IsZero() || now.Sub(h.windowStart) >= h.opts.Window {
+			h.windowStart = now
+			clear(h.counts)
+		}
+		k := sampleKey{level: r.Level, msg: r.Message}
+		h.counts[k]++
+		n := h.counts[k]
+		if n > h.opts.First {
+			if h.opts.Thereafter <= 0 || (n-h.opts.First)%h.opts.Thereafter != 0 {
+				return false
+			}
+		}
+	}
+	if rate, ok := h.opts.Rates[r.Level]; ok {
+		if rate <= 0 || (rate < 1 && h.rnd() >= rate) {
+			return false
+		}
+	}
+	return true
+}
+
+// summaryLocked builds a summary record when the summary interval has elapsed
+// and records were suppressed. h.mu must be held.
+func (h *SamplingHandler) summaryLocked(now time.Time) (Record, bool) {
+	if h.opts.SummaryInterval <= 0 {
+		return Record{}, false
+	}
+	if h.lastSummary.IsZero() {
+		h.lastSummary = now
+		return Record{}, false
+	}
+	if now.Sub(h.lastSummary) < h.opts.SummaryInterval {
+		return Record{}, false
+	}
+	h.lastSummary = now
+	return h.takeSummaryLocked(now)
+}
+
+func (h *SamplingHandler) takeSummaryLocked(now time.Time) (Record, bool) {
+	if h.suppressed == 0 {
+		return Record{}, false
+	}
+	n := h.suppressed
+	h.suppressed = 0
+	return Record{
+		Time:    now,
+		Level:   h.opts.SummaryLevel,
+		Message: "suppressed " + strconv.FormatUint(n, 10) + " similar messages",
+		Prefix:  h.last.Prefix,
+		Attrs:   []Attr{{Key: "suppressed", Value: n}},
+		Flags:   h.last.Flags,
+	}, true
+}
+
+// Flush emits a summary record for records suppressed since the last summary, if any.
+func (h *SamplingHandler) Flush() error {
+	h.mu.Lock()
+	now := time.Now()
+	h.lastSummary = now
+	summary, emit := h.takeSummaryLocked(now)
+	h.mu.Unlock()
+	if !emit {
+		return nil
+	}
+	return h.next.Handle(summary)
+}
+
+// Stats returns the number of records forwarded and dropped so far.
+func (h *SamplingHandler) Stats() SamplingStats {
+	return SamplingStats{Sampled: h.sampled.Load(), Dropped: h.dropped.Load()}
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureHandler records every Record it receives.
+type captureHandler struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (h *captureHandler) Handle(r Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *captureHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]string, 0, len(h.records))
+	for _, r := range h.records {
+		out = append(out, r.Message)
+	}
+	return out
+}
+
+func TestSamplingHandler_FirstThenEveryMth(t *testing.T) {
+	c := &captureHandler{}
+	h := NewSamplingHandler(c, SamplingOptions{First: 2, Thereafter: 3, Window: time.Minute})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, h.Handle(Record{Time: base, Level: LevelDebug, Message: "hot"}))
+	}
+	// kept: 1, 2, 5, 8
+	assert.Len(t, c.records, 4)
+	assert.Equal(t, SamplingStats{Sampled: 4, Dropped: 6}, h.Stats())
+
+	// different message uses its own counter
+	assert.NoError(t, h.Handle(Record{Time: base, Level: LevelDebug, Message: "other"}))
+	assert.Len(t, c.records, 5)
+
+	// window reset lets the key through again
+	assert.NoError(t, h.Handle(Record{Time: base.Add(time.Minute), Level: LevelDebug, Message: "hot"}))
+	assert.Len(t, c.records, 6)
+}
+
+func TestSamplingHandler_Rates(t *testing.T) {
+	c := &captureHandler{}
+	h := NewSamplingHandler(c, SamplingOptions{Rates: map[Level]float64{LevelDebug: 0.5, LevelTrace: 0}})
+	vals := []float64{0.1, 0.9}
+	i := 0
+	h.rnd = func() float64 { v := vals[i%len(vals)]; i++; return v }
+
+	_ = h.Handle(Record{Level: LevelDebug, Message: "a"})
+	_ = h.Handle(Record{Level: LevelDebug, Message: "b"})
+	_ = h.Handle(Record{Level: LevelTrace, Message: "t"})
+	_ = h.Handle(Record{Level: LevelInfo, Message: "i"})
+	assert.Equal(t, []string{"a", "i"}, c.messages())
+	assert.Equal(t, uint64(2), h.Stats().Dropped)
+}
+
+func TestSamplingHandler_Summary(t *testing.T) {
+	c := &captureHandler{}
+	h := NewSamplingHandler(c, SamplingOptions{First: 1, Window: time.Hour, SummaryInterval: 10 * time.Second})
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		_ = h.Handle(Record{Time: base, Level: LevelDebug, Message: "x", Prefix: "p"})
+	}
+	assert.Equal(t, []string{"x"}, c.messages())
+
+	_ = h.Handle(Record{Time: base.Add(10 * time.Second), Level: LevelInfo, Message: "next"})
+	assert.Equal(t, []string{"x", "suppressed 4 similar messages", "next"}, c.messages())
+	assert.Equal(t, "p", c.records[1].Prefix)
+	assert.Equal(t, LevelInfo, c.records[1].Level)
+
+	// Flush with nothing suppressed is a no-op; after drops it emits a summary
+	assert.NoError(t, h.Flush())
+	assert.Len(t, c.records, 3)
+	_ = h.Handle(Record{Time: base.Add(11 * time.Second), Level: LevelDebug, Message: "x"})
+	assert.NoError(t, h.Flush())
+	assert.Equal(t, "suppressed 1 similar messages", c.records[3].Message)
+}
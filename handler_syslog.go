@@ -0,0 +1,55 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"log/syslog"
+	"strings"
+)
+
+// SyslogHandler sends records to a syslog daemon, mapping levels to syslog
+// severities.
+type SyslogHandler struct {
+	w *syslog.Writer
+}
+
+// NewSyslogHandler connects to the syslog daemon at raddr over network
+// ("udp", "tcp", or "" for the local daemon) and tags messages with tag.
+func NewSyslogHandler(network, raddr, tag string) (*SyslogHandler, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_USER|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogHandler{w: w}, nil
+}
+
+func (h *SyslogHandler) Handle(r Record) error {
+	b := &strings.Builder{}
+	if r.Prefix != "" {
+		b.WriteString("[" + r.Prefix + "] ")
+	}
+	b.WriteString(formatMessage(r.Message))
+	for _, a := range r.Attrs {
+		b.WriteString(" " + a.Key + "=" + formatAttrValue(a.Value))
+	}
+	m := b.String()
+	switch {
+	case r.Level >= LevelAlert:
+		return h.w.Alert(m)
+	case r.Level >= LevelCritical:
+		return h.w.Crit(m)
+	case r.Level >= LevelError:
+		return h.w.Err(m)
+	case r.Level >= LevelWarn:
+		return h.w.Warning(m)
+	case r.Level >= LevelNotice:
+		return h.w.Notice(m)
+	case r.Level >= LevelInfo:
+		return h.w.Info(m)
+	default:
+		return h.w.Debug(m)
+	}
+}
+
+// Close closes the connection to the daemon.
+func (h *SyslogHandler) Close() error { return h.w.Close() }
@@ -0,0 +1,18 @@
+//go:build windows || plan9
+
+package log
+
+import "errors"
+
+// SyslogHandler is not available on this platform.
+type SyslogHandler struct{}
+
+// NewSyslogHandler always fails on this platform.
+func NewSyslogHandler(network, raddr, tag string) (*SyslogHandler, error) {
+	return nil, errors.New("log: syslog is not supported on this platform")
+}
+
+func (h *SyslogHandler) Handle(Record) error { return nil }
+
+// Close does nothing.
+func (h *SyslogHandler) Close() error { return nil }
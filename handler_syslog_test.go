@@ -0,0 +1,39 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogHandler(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	h, err := NewSyslogHandler("udp", pc.LocalAddr().String(), "app")
+	require.NoError(t, err)
+	defer h.Close()
+
+	read := func() string {
+		buf := make([]byte, 1024)
+		_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+
+	require.NoError(t, h.Handle(Record{Level: LevelError, Prefix: "db", Message: "down", Attrs: []Attr{{Key: "retry", Value: 3}}}))
+	msg := read()
+	assert.Contains(t, msg, "<11>") // user facility, err severity
+	assert.Contains(t, msg, "app[")
+	assert.Contains(t, msg, "[db] down retry=3")
+
+	require.NoError(t, h.Handle(Record{Level: LevelDebug, Message: "details"}))
+	assert.Contains(t, read(), "<15>")
+}
@@ -0,0 +1,236 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LiveTailOptions configures LiveTail.
+type LiveTailOptions struct {
+	// Backlog is the number of recent records replayed to each new client
+	// (default 100; negative disables replay).
+	Backlog int
+	// Buffer is the number of records queued per client (default 256). A client
+	// that falls further behind is sent an error event and disconnected.
+	Buffer int
+	// WriteTimeout bounds each write to a client (default 5s).
+	WriteTimeout time.Duration
+	// Heartbeat is the interval of SSE comment lines that keep idle streams
+	// open through proxies (default 15s).
+	Heartbeat time.Duration
+}
+
+// LiveTail is both a Handler and an http.Handler: records it handles are
+// streamed to connected HTTP clients as Server-Sent Events (default),
+// NDJSON (?format=ndjson) or plain text (?format=text). Clients filter with
+// ?level=warn and ?attr=key:value (repeatable; all must match).
+//
+//	tail := log.NewLiveTail(log.LiveTailOptions{})
+//	log.AddHandler(log.LevelDebug, tail)
+//	mux.Handle("/debug/logs", tail)
+type LiveTail struct {
+	opts LiveTailOptions
+	b    *Broadcaster
+
+	mu      sync.Mutex // orders backlog snapshots with broadcasts
+	backlog []Record
+	next    int
+	full    bool
+}
+
+// NewLiveTail returns a LiveTail with defaults applied to opts.
+func NewLiveTail(opts LiveTailOptions) *LiveTail {
+	if opts.Backlog == 0 {
+		opts.Backlog = 100
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 256
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 5 * time.Second
+	}
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = 15 * time.Second
+	}
+	t := &LiveTail{opts: opts, b: NewBroadcaster(opts.Buffer)}
+	if opts.Backlog > 0 {
+		t.backlog = make([]Record, opts.Backlog)
+	}
+	return t
+}
+
+func (t *LiveTail) Handle(r Record) error {
+	r.Attrs = append([]Attr(nil), r.Attrs...)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.backlog) > 0 {
+		t.backlog[t.next] = r
+		t.next = (t.next + 1) % len(t.backlog)
+		if t.next == 0 {
+			t.full = true
+		}
+	}
+	return t.b.Handle(r)
+}
+
+// Clients returns the number of connected clients.
+func (t *LiveTail) Clients() int { return t.b.Len() }
+
+// Close disconnects all clients. Later clients receive only the backlog.
+func (t *LiveTail) Close() { t.b.Close() }
+
+// subscribe atomically snapshots the backlog and registers a subscriber, so
+// no record is missed or repeated between replay and streaming.
+func (t *LiveTail) subscribe(match func(Record) bool) ([]Record, *Subscription) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var replay []Record
+	if t.full {
+		replay = append(replay, t.backlog[t.next:]...)
+	}
+	replay = append(replay, t.backlog[:t.next]...)
+	n := 0
+	for _, r := range replay {
+		if match(r) {
+			replay[n] = r
+			n++
+		}
+	}
+	return replay[:n], t.b.Subscribe(match)
+}
+
+// tailFilter builds the record filter from the request's query parameters.
+func tailFilter(r *http.Request) (func(Record) bool, error) {
+	q := r.URL.Query()
+	min := LevelAll
+	if s := q.Get("level"); s != "" {
+		lvl, err := ParseLevel(s)
+		if err != nil {
+			return nil, err
+		}
+		min = lvl
+	}
+	type kv struct{ k, v string }
+	var attrs []kv
+	for _, a := range q["attr"] {
+		k, v, ok := strings.Cut(a, ":")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("log: attr filter %q is not key:value", a)
+		}
+		attrs = append(attrs, kv{k, v})
+	}
+	return func(rec Record) bool {
+		if rec.Level < min {
+			return false
+		}
+	next:
+		for _, f := range attrs {
+			for _, a := range rec.Attrs {
+				if a.Key == f.k && fmt.Sprint(a.Value) == f.v {
+					continue next
+				}
+			}
+			return false
+		}
+		return true
+	}, nil
+}
+
+// errSlowClient ends a stream whose client could not keep up.
+var errSlowClient = errors.New("client too slow, records dropped")
+
+func (t *LiveTail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	match, err := tailFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "sse":
+		format = "sse"
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	case "ndjson", "json":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	default:
+		http.Error(w, fmt.Sprintf("log: unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	var buf bytes.Buffer
+	enc := NewJSONHandler(&buf)
+	text := &WriterHandler{w: &buf}
+	send := func(rec Record) error {
+		buf.Reset()
+		switch format {
+		case "sse":
+			buf.WriteString("data: ")
+			_ = enc.Handle(rec) // JSON line ends in "\n"
+			buf.WriteString("\n")
+		case "text":
+			_ = text.Handle(rec)
+		default:
+			_ = enc.Handle(rec)
+		}
+		return t.write(rc, w, buf.Bytes())
+	}
+
+	replay, sub := t.subscribe(match)
+	defer sub.Close()
+	for _, rec := range replay {
+		if send(rec) != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return
+	}
+	heartbeat := time.NewTicker(t.opts.Heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case rec, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if sub.Stats().Dropped > 0 {
+				if format == "sse" {
+					_ = t.write(rc, w, []byte("event: error\ndata: "+errSlowClient.Error()+"\n\n"))
+				}
+				return
+			}
+			if send(rec) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if format == "sse" && t.write(rc, w, []byte(": ping\n\n")) != nil {
+				return
+			}
+		}
+	}
+}
+
+// write sends p and flushes it, bounded by WriteTimeout where supported.
+func (t *LiveTail) write(rc *http.ResponseController, w http.ResponseWriter, p []byte) error {
+	_ = rc.SetWriteDeadline(time.Now().Add(t.opts.WriteTimeout))
+	if _, err := w.Write(p); err != nil {
+		return err
+	}
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readLines returns a channel of the non-empty lines of body.
+func readLines(body io.Reader) <-chan string {
+	out := make(chan string, 64)
+	go func() {
+		defer close(out)
+		sc := bufio.NewScanner(body)
+		for sc.Scan() {
+			if sc.Text() != "" {
+				out <- sc.Text()
+			}
+		}
+	}()
+	return out
+}
+
+func nextLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case l := <-lines:
+		return l
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a line")
+		return ""
+	}
+}
+
+func TestLiveTail_SSEWithBacklogAndFilters(t *testing.T) {
+	tail := NewLiveTail(LiveTailOptions{Backlog: 2})
+	l := New(io.Discard, "", 0)
+	l.AddHandler(LevelAll, tail)
+	srv := httptest.NewServer(tail)
+	defer srv.Close()
+
+	l.Info("old", "user", "ann")
+	l.Warn("backlog 1", "user", "ann")
+	l.Error("backlog 2", "user", "bob")
+	l.Error("backlog 3", "user", "ann")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?level=warn&attr=user:ann", nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	lines := readLines(resp.Body)
+
+	// only the last two records are kept, and only matching ones replayed
+	assert.Equal(t, `data: {"attrs":{"user":"ann"},"level":"ERROR   ","msg":"backlog 3","time":""}`, nextLine(t, lines))
+
+	assert.Eventually(t, func() bool { return tail.Clients() == 1 }, time.Second, time.Millisecond)
+	l.Info("too low", "user", "ann")
+	l.Warn("other user", "user", "bob")
+	l.Warn("live", "user", "ann")
+	assert.Equal(t, `data: {"attrs":{"user":"ann"},"level":"WARN    ","msg":"live","time":""}`, nextLine(t, lines))
+
+	cancel()
+	assert.Eventually(t, func() bool { return tail.Clients() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestLiveTail_NDJSONAndText(t *testing.T) {
+	tail := NewLiveTail(LiveTailOptions{})
+	_ = tail.Handle(Record{Level: LevelInfo, Message: "hi", Attrs: []Attr{{Key: "n", Value: 1}}})
+	tail.Close() // clients get the backlog, then the stream ends
+
+	rec := httptest.NewRecorder()
+	tail.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?format=ndjson", nil))
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `{"attrs":{"n":1},"level":"INFO    ","msg":"hi","time":""}`+"\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	tail.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?format=text", nil))
+	assert.Equal(t, "INFO     hi n=1\n", rec.Body.String())
+}
+
+func TestLiveTail_BadQuery(t *testing.T) {
+	tail := NewLiveTail(LiveTailOptions{})
+	for _, q := range []string{"level=loud", "attr=novalue", "format=xml"} {
+		rec := httptest.NewRecorder()
+		tail.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+q, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, q)
+	}
+}
+
+// gatedRW blocks writes until gate is closed, simulating a stalled client.
+type gatedRW struct {
+	*httptest.ResponseRecorder
+	gate    chan struct{}
+	writing chan struct{}
+}
+
+func (g *gatedRW) Write(p []byte) (int, error) {
+	select {
+	case g.writing <- struct{}{}:
+	default:
+	}
+	<-g.gate
+	return g.ResponseRecorder.Write(p)
+}
+
+func TestLiveTail_SlowClientDisconnected(t *testing.T) {
+	tail := NewLiveTail(LiveTailOptions{Backlog: -1, Buffer: 2})
+	w := &gatedRW{ResponseRecorder: httptest.NewRecorder(), gate: make(chan struct{}), writing: make(chan struct{}, 1)}
+	done := make(chan struct{})
+	go func() {
+		tail.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return tail.Clients() == 1 }, time.Second, time.Millisecond)
+
+	_ = tail.Handle(Record{Message: "first"})
+	<-w.writing // the handler is stuck writing "first"
+	for i := 0; i < 5; i++ {
+		_ = tail.Handle(Record{Message: "more"})
+	}
+	close(w.gate)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow client was not disconnected")
+	}
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, `data: {"level":"INFO    ","msg":"first","time":""}`), body)
+	assert.True(t, strings.HasSuffix(body, "event: error\ndata: client too slow, records dropped\n\n"), body)
+	assert.Equal(t, 0, tail.Clients())
+}
@@ -3,14 +3,21 @@ package log
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode"
 )
 
 // WriterHandler is a basic text writer handler.
 type WriterHandler struct {
-	w io.Writer
+	mu sync.Mutex
+	w  io.Writer
 }
 
+// NewWriterHandler creates a WriterHandler writing to w.
+func NewWriterHandler(w io.Writer) *WriterHandler { return &WriterHandler{w: w} }
+
 func (h *WriterHandler) Handle(r Record) error {
 	// Minimal text line: timestamp level [prefix] message key=val ...
 	b := &strings.Builder{}
@@ -27,15 +34,54 @@ func (h *WriterHandler) Handle(r Record) error {
 	}
 	if r.Message != "" {
 		b.WriteByte(' ')
-		b.WriteString(r.Message)
+		b.WriteString(formatMessage(r.Message))
 	}
 	for _, a := range r.Attrs {
 		b.WriteByte(' ')
 		b.WriteString(a.Key)
 		b.WriteByte('=')
-		b.WriteString(fmt.Sprint(a.Value))
+		b.WriteString(formatAttrValue(a.Value))
 	}
 	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	_, err := io.WriteString(h.w, b.String())
 	return err
 }
+
+// formatMessage renders a record message for text output. Like the standard
+// library, a single trailing newline is dropped; other control characters
+// except tab and ESC (used by ANSI colors) are escaped, e.g. \n, so a message
+// cannot start a forged line.
+func formatMessage(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	if !strings.ContainsFunc(s, escapedInMessage) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if !escapedInMessage(r) {
+			b.WriteRune(r)
+			continue
+		}
+		q := strconv.QuoteRune(r)
+		b.WriteString(q[1 : len(q)-1])
+	}
+	return b.String()
+}
+
+func escapedInMessage(r rune) bool { return unicode.IsControl(r) && r != '\t' && r != '\x1b' }
+
+// formatAttrValue renders an attr value for text output, quoting it when it
+// contains control characters such as newlines so each record stays on one
+// line. A StackTrace is kept readable with its continuation lines indented.
+func formatAttrValue(v any) string {
+	if st, ok := v.(StackTrace); ok {
+		return strings.ReplaceAll(strings.TrimRight(string(st), "\n"), "\n", "\n    ")
+	}
+	s := fmt.Sprint(v)
+	if strings.ContainsFunc(s, unicode.IsControl) {
+		return strconv.Quote(s)
+	}
+	return s
+}
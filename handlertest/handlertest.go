@@ -0,0 +1,289 @@
+// Package handlertest checks that a log.Handler implementation behaves like the
+// handlers in github.com/chrisjoyce911/log, in the spirit of testing/slogtest.
+//
+// A test supplies a constructor for the handler and a parser that turns what
+// the handler wrote into one map per record. Parsed maps use the keys "time",
+// "level", "msg", "prefix" and "source", and each attr under its own key.
+// Dotted attr keys such as "http.method" may be returned either as is or as
+// nested maps.
+package handlertest
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chrisjoyce911/log"
+)
+
+// Option declares a documented limitation of the handler under test so the
+// suite does not expect it.
+type Option int
+
+const (
+	// NoAttrs skips checks on attrs, for handlers that do not render them.
+	NoAttrs Option = iota + 1
+	// NoPrefix skips checks on the prefix, for handlers that do not render it.
+	NoPrefix
+)
+
+// Run runs the conformance suite as subtests of t. newHandler is called once
+// per subtest and must return a handler writing to fresh output; parseOutput
+// is then called with the same subtest and returns the records written so far.
+func Run(t *testing.T, newHandler func(*testing.T) log.Handler, parseOutput func(*testing.T) []map[string]any, opts ...Option) {
+	s := &suite{newHandler: newHandler, parse: parseOutput}
+	for _, o := range opts {
+		switch o {
+		case NoAttrs:
+			s.noAttrs = true
+		case NoPrefix:
+			s.noPrefix = true
+		}
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) { c.run(t, s) })
+	}
+}
+
+type suite struct {
+	newHandler func(*testing.T) log.Handler
+	parse      func(*testing.T) []map[string]any
+	noAttrs    bool
+	noPrefix   bool
+}
+
+// handle sends recs to a new handler and returns the parsed output, failing
+// the test unless there is exactly one entry per record.
+func (s *suite) handle(t *testing.T, recs ...log.Record) []map[string]any {
+	t.Helper()
+	h := s.newHandler(t)
+	for _, r := range recs {
+		if err := h.Handle(r); err != nil {
+			t.Fatalf("Handle(%q) returned %v", r.Message, err)
+		}
+	}
+	got := s.parse(t)
+	if len(got) != len(recs) {
+		t.Fatalf("handled %d records, parsed %d entries: %v", len(recs), len(got), got)
+	}
+	return got
+}
+
+var testTime = time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+
+func record(level log.Level, msg string, kv ...any) log.Record {
+	r := log.Record{Time: testTime, Level: level, Message: msg, Flags: log.LstdFlags}
+	for i := 0; i+1 < len(kv); i += 2 {
+		r.Attrs = append(r.Attrs, log.Attr{Key: kv[i].(string), Value: kv[i+1]})
+	}
+	return r
+}
+
+// lookup finds key in m, descending into nested maps for dotted keys.
+func lookup(m map[string]any, key string) (any, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	head, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	sub, isMap := m[head].(map[string]any)
+	if !isMap {
+		return nil, false
+	}
+	return lookup(sub, rest)
+}
+
+func str(v any) string {
+	if f, ok := v.(float64); ok && f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10) // JSON numbers
+	}
+	return fmt.Sprint(v)
+}
+
+func wantField(t *testing.T, m map[string]any, key string, want any) {
+	t.Helper()
+	got, ok := lookup(m, key)
+	if !ok {
+		t.Errorf("missing %q in %v", key, m)
+		return
+	}
+	if str(got) != str(want) {
+		t.Errorf("%s: got %q, want %q", key, str(got), str(want))
+	}
+}
+
+func wantLevel(t *testing.T, m map[string]any, level log.Level) {
+	t.Helper()
+	got, ok := m["level"]
+	if !ok {
+		t.Errorf("missing level in %v", m)
+		return
+	}
+	if want := strings.TrimSpace(level.String()); !strings.EqualFold(strings.TrimSpace(str(got)), want) {
+		t.Errorf("level: got %q, want %q", str(got), want)
+	}
+}
+
+func emptyOrMissing(m map[string]any, key string) bool {
+	v, ok := m[key]
+	return !ok || v == nil || str(v) == ""
+}
+
+var cases = []struct {
+	name string
+	run  func(*testing.T, *suite)
+}{
+	{"message", func(t *testing.T, s *suite) {
+		m := s.handle(t, record(log.LevelInfo, "hello world"))[0]
+		wantField(t, m, "msg", "hello world")
+		wantLevel(t, m, log.LevelInfo)
+		if emptyOrMissing(m, "time") {
+			t.Errorf("missing time for a record with Ldate|Ltime: %v", m)
+		}
+	}},
+	{"levels", func(t *testing.T, s *suite) {
+		levels := []log.Level{log.LevelTrace, log.LevelVerbose, log.LevelDebug, log.LevelDetail, log.LevelInfo,
+			log.LevelNotice, log.LevelWarn, log.LevelError, log.LevelCritical, log.LevelAlert, log.LevelFatal, log.LevelPanic}
+		recs := make([]log.Record, len(levels))
+		for i, lv := range levels {
+			recs[i] = record(lv, "at "+strings.TrimSpace(lv.String()))
+		}
+		for i, m := range s.handle(t, recs...) {
+			wantLevel(t, m, levels[i])
+		}
+	}},
+	{"zero time", func(t *testing.T, s *suite) {
+		r := record(log.LevelInfo, "no time")
+		r.Time = time.Time{}
+		m := s.handle(t, r)[0]
+		if !emptyOrMissing(m, "time") {
+			t.Errorf("zero Time should not be rendered, got %q", str(m["time"]))
+		}
+		wantField(t, m, "msg", "no time")
+	}},
+	{"empty message", func(t *testing.T, s *suite) {
+		m := s.handle(t, record(log.LevelWarn, "", "k", "v"))[0]
+		if !emptyOrMissing(m, "msg") {
+			t.Errorf("empty message rendered as %q", str(m["msg"]))
+		}
+		wantLevel(t, m, log.LevelWarn)
+		if !s.noAttrs {
+			wantField(t, m, "k", "v")
+		}
+	}},
+	{"prefix", func(t *testing.T, s *suite) {
+		if s.noPrefix {
+			t.Skip("handler does not render the prefix")
+		}
+		r := record(log.LevelInfo, "with prefix")
+		r.Prefix = "svc"
+		wantField(t, s.handle(t, r)[0], "prefix", "svc")
+	}},
+	{"attrs", func(t *testing.T, s *suite) {
+		if s.noAttrs {
+			t.Skip("handler does not render attrs")
+		}
+		m := s.handle(t, record(log.LevelInfo, "attrs", "str", "v", "int", 42, "bool", true))[0]
+		wantField(t, m, "str", "v")
+		wantField(t, m, "int", 42)
+		wantField(t, m, "bool", true)
+	}},
+	{"special characters", func(t *testing.T, s *suite) {
+		values := []string{"with spaces", `quote"d`, "equals=sign", "tab\tand\nnewline", "unicode ✓ é"}
+		var kv []any
+		for i, v := range values {
+			kv = append(kv, "k"+strconv.Itoa(i), v)
+		}
+		m := s.handle(t, record(log.LevelInfo, `msg "quoted" ✓`, kv...))[0]
+		wantField(t, m, "msg", `msg "quoted" ✓`)
+		if s.noAttrs {
+			return
+		}
+		for i, v := range values {
+			wantField(t, m, "k"+strconv.Itoa(i), v)
+		}
+	}},
+	{"multi-line message", func(t *testing.T, s *suite) {
+		// one entry, not a forged second record; the message may be escaped
+		m := s.handle(t, record(log.LevelInfo, "first\nERROR forged k=v"))[0]
+		if msg := str(m["msg"]); !strings.HasPrefix(msg, "first") {
+			t.Errorf("msg: got %q, want it to start with %q", msg, "first")
+		}
+	}},
+	{"groups", func(t *testing.T, s *suite) {
+		if s.noAttrs {
+			t.Skip("handler does not render attrs")
+		}
+		m := s.handle(t, record(log.LevelInfo, "grouped", "http.method", "GET", "http.status_code", 200, "url.path", "/x"))[0]
+		wantField(t, m, "http.method", "GET")
+		wantField(t, m, "http.status_code", 200)
+		wantField(t, m, "url.path", "/x")
+	}},
+	{"concurrent", func(t *testing.T, s *suite) {
+		const goroutines, each = 8, 100
+		h := s.newHandler(t)
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < each; i++ {
+					if err := h.Handle(record(log.LevelInfo, fmt.Sprintf("g%d-%d", g, i), "g", g)); err != nil {
+						t.Errorf("Handle: %v", err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		got := s.parse(t)
+		if len(got) != goroutines*each {
+			t.Fatalf("handled %d records, parsed %d entries", goroutines*each, len(got))
+		}
+		msgs := make([]string, len(got))
+		for i, m := range got {
+			msgs[i] = str(m["msg"])
+		}
+		sort.Strings(msgs)
+		for i := 1; i < len(msgs); i++ {
+			if msgs[i] == msgs[i-1] {
+				t.Fatalf("message %q parsed twice; output interleaved?", msgs[i])
+			}
+		}
+	}},
+	{"large record", func(t *testing.T, s *suite) {
+		msg := strings.Repeat("m", 64<<10)
+		val := strings.Repeat("v", 1<<20)
+		m := s.handle(t, record(log.LevelError, msg, "big", val))[0]
+		if got := str(m["msg"]); got != msg {
+			t.Errorf("message of %d bytes parsed as %d bytes", len(msg), len(got))
+		}
+		if s.noAttrs {
+			return
+		}
+		if got, _ := lookup(m, "big"); str(got) != val {
+			t.Errorf("attr of %d bytes parsed as %d bytes", len(val), len(str(got)))
+		}
+	}},
+	{"source", func(t *testing.T, s *suite) {
+		pc, file, line, _ := runtime.Caller(0)
+		r := record(log.LevelInfo, "with source")
+		r.PC = pc
+		r.Flags |= log.Lshortfile
+		m := s.handle(t, r)[0]
+		src, ok := m["source"]
+		if !ok {
+			t.Skip("handler does not render source")
+		}
+		if want := fmt.Sprintf("%s:%d", file[strings.LastIndexByte(file, '/')+1:], line); !strings.HasSuffix(str(src), want) {
+			t.Errorf("source: got %q, want suffix %q", str(src), want)
+		}
+	}},
+}
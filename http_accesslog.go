@@ -0,0 +1,244 @@
+package log
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessLogFormat selects how HTTPLogging renders the access line.
+type AccessLogFormat int
+
+const (
+	// FormatDefault is the colored "METHOD addr path" message with status,
+	// bytes and duration attrs.
+	FormatDefault AccessLogFormat = iota
+	// FormatCommon renders the Apache Common Log Format as the message.
+	FormatCommon
+	// FormatCombined renders the Apache Combined Log Format (CLF plus referer
+	// and user agent) as the message.
+	FormatCombined
+	// FormatTemplate renders HTTPLogOptions.Template as the message.
+	FormatTemplate
+	// FormatStructured logs a fixed "http request" message with all request
+	// data as attrs (http.method, url.path, http.status_code, client.ip, ...),
+	// suitable for JSON outputs.
+	FormatStructured
+)
+
+// Apache-style templates used by FormatCommon and FormatCombined.
+const (
+	CommonLogTemplate   = `%h %l %u %t "%r" %>s %b`
+	CombinedLogTemplate = `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"`
+)
+
+// accessInfo carries what an access log template can reference.
+type accessInfo struct {
+	r        *http.Request
+	header   http.Header // response header
+	start    time.Time
+	dur      time.Duration
+	status   int
+	bytes    int64
+	clientIP string
+	rawQuery string // query string after redaction
+}
+
+// accessToken renders one piece of an access log line.
+type accessToken func(b *strings.Builder, a *accessInfo)
+
+// parseAccessTemplate compiles an Apache mod_log_config style template.
+// Supported directives: %h %a %l %u %t %r %s %>s %b %B %D %T %m %U %q %H
+// %{Name}i (request header), %{Name}o (response header) and %%.
+// Unknown directives are written verbatim. Values taken from the request or
+// response are escaped as Apache does (see escapeAccess).
+func parseAccessTemplate(tmpl string) []accessToken {
+	var toks []accessToken
+	lit := func(s string) {
+		if s != "" {
+			toks = append(toks, func(b *strings.Builder, _ *accessInfo) { b.WriteString(s) })
+		}
+	}
+	for {
+		i := strings.IndexByte(tmpl, '%')
+		if i < 0 || i == len(tmpl)-1 {
+			lit(tmpl)
+			return toks
+		}
+		lit(tmpl[:i])
+		rest := tmpl[i+1:]
+		if rest[0] == '{' {
+			end := strings.IndexByte(rest, '}')
+			if end > 0 && end+1 < len(rest) {
+				name := rest[1:end]
+				switch rest[end+1] {
+				case 'i':
+					toks = append(toks, func(b *strings.Builder, a *accessInfo) { b.WriteString(dash(escapeAccess(a.r.Header.Get(name)))) })
+					tmpl = rest[end+2:]
+					continue
+				case 'o':
+					toks = append(toks, func(b *strings.Builder, a *accessInfo) { b.WriteString(dash(escapeAccess(a.header.Get(name)))) })
+					tmpl = rest[end+2:]
+					continue
+				}
+			}
+			lit("%")
+			tmpl = rest
+			continue
+		}
+		n := 1
+		if strings.HasPrefix(rest, ">s") {
+			n = 2
+		}
+		if tok := accessDirective(rest[:n]); tok != nil {
+			toks = append(toks, tok)
+		} else {
+			lit("%" + rest[:n])
+		}
+		tmpl = rest[n:]
+	}
+}
+
+func accessDirective(d string) accessToken {
+	switch d {
+	case "%":
+		return func(b *strings.Builder, _ *accessInfo) { b.WriteByte('%') }
+	case "h", "a":
+		return func(b *strings.Builder, a *accessInfo) { b.WriteString(dash(a.clientIP)) }
+	case "l":
+		return func(b *strings.Builder, _ *accessInfo) { b.WriteByte('-') }
+	case "u":
+		return func(b *strings.Builder, a *accessInfo) {
+			user, _, _ := a.r.BasicAuth()
+			b.WriteString(dash(escapeAccess(user)))
+		}
+	case "t":
+		return func(b *strings.Builder, a *accessInfo) {
+			b.WriteByte('[')
+			b.WriteString(a.start.Format("02/Jan/2006:15:04:05 -0700"))
+			b.WriteByte(']')
+		}
+	case "r":
+		return func(b *strings.Builder, a *accessInfo) {
+			b.WriteString(escapeAccess(a.r.Method))
+			b.WriteByte(' ')
+			b.WriteString(escapeAccess(a.r.URL.EscapedPath()))
+			if a.rawQuery != "" {
+				b.WriteByte('?')
+				b.WriteString(escapeAccess(a.rawQuery))
+			}
+			b.WriteByte(' ')
+			b.WriteString(escapeAccess(a.r.Proto))
+		}
+	case "s", ">s":
+		return func(b *strings.Builder, a *accessInfo) { b.WriteString(strconv.Itoa(a.status)) }
+	case "b":
+		return func(b *strings.Builder, a *accessInfo) {
+			if a.bytes == 0 {
+				b.WriteByte('-')
+				return
+			}
+			b.WriteString(strconv.FormatInt(a.bytes, 10))
+		}
+	case "B":
+		return func(b *strings.Builder, a *accessInfo) { b.WriteString(strconv.FormatInt(a.bytes, 10)) }
+	case "D":
+		return func(b *strings.Builder, a *accessInfo) { b.WriteString(strconv.FormatInt(a.dur.Microseconds(), 10)) }
+	case "T":
+		return func(b *strings.Builder, a *accessInfo) {
+			b.WriteString(strconv.FormatInt(int64(a.dur/time.Second), 10))
+		}
+	case "m":
+		return func(b *strings.Builder, a *accessInfo) { b.WriteString(escapeAccess(a.r.Method)) }
+	case "U":
+		return func(b *strings.Builder, a *accessInfo) { b.WriteString(escapeAccess(a.r.URL.Path)) }
+	case "q":
+		return func(b *strings.Builder, a *accessInfo) {
+			if a.rawQuery != "" {
+				b.WriteByte('?')
+				b.WriteString(escapeAccess(a.rawQuery))
+			}
+		}
+	case "H":
+		return func(b *strings.Builder, a *accessInfo) { b.WriteString(escapeAccess(a.r.Proto)) }
+	}
+	return nil
+}
+
+// escapeAccess escapes a request-controlled value like Apache's log escaping:
+// quotes and backslashes get a backslash, and control and non-ASCII bytes are
+// written as \xNN, so a value cannot end a quoted field or start a new line.
+func escapeAccess(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= ' ' && s[i] < 0x7f && s[i] != '"' && s[i] != '\\' {
+		i++
+	}
+	if i == len(s) {
+		return s
+	}
+	const hex = "0123456789abcdef"
+	var b strings.Builder
+	b.WriteString(s[:i])
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c >= 0x7f:
+			b.WriteString(`\x`)
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func renderAccess(toks []accessToken, a *accessInfo) string {
+	var b strings.Builder
+	for _, t := range toks {
+		t(&b, a)
+	}
+	return b.String()
+}
+
+// structuredAccessAttrs returns the attrs used by FormatStructured.
+func structuredAccessAttrs(a *accessInfo, query string) []any {
+	attrs := []any{
+		"http.method", a.r.Method,
+		"url.path", a.r.URL.Path,
+	}
+	if query != "" {
+		attrs = append(attrs, "url.query", query)
+	}
+	attrs = append(attrs,
+		"http.status_code", a.status,
+		"http.response.body.size", a.bytes,
+		"duration", a.dur.String(),
+		"client.ip", a.clientIP,
+		"network.peer.address", remoteHost(a.r),
+		"user_agent.original", a.r.UserAgent(),
+	)
+	if ref := a.r.Referer(); ref != "" {
+		attrs = append(attrs, "http.referer", ref)
+	}
+	return attrs
+}
+
+// remoteHost returns the host part of r.RemoteAddr.
+func remoteHost(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
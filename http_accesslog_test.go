@@ -0,0 +1,123 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccessTemplate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/a/b?x=1", nil)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.SetBasicAuth("bob", "pw")
+	req.Header.Set("Referer", "https://ref.example/")
+	req.Header.Set("User-Agent", "curl/8")
+	hdr := http.Header{"Content-Type": {"text/plain"}}
+	ai := &accessInfo{
+		r: req, header: hdr,
+		start:  time.Date(2024, 3, 4, 5, 6, 7, 0, time.FixedZone("", -7*3600)),
+		dur:    1500 * time.Millisecond,
+		status: 201, bytes: 42, clientIP: "10.0.0.1", rawQuery: "x=1",
+	}
+	assert.Equal(t, `10.0.0.1 - bob [04/Mar/2024:05:06:07 -0700] "GET /a/b?x=1 HTTP/1.1" 201 42`,
+		renderAccess(parseAccessTemplate(CommonLogTemplate), ai))
+	assert.Equal(t, `10.0.0.1 - bob [04/Mar/2024:05:06:07 -0700] "GET /a/b?x=1 HTTP/1.1" 201 42 "https://ref.example/" "curl/8"`,
+		renderAccess(parseAccessTemplate(CombinedLogTemplate), ai))
+	assert.Equal(t, `GET /a/b ?x=1 HTTP/1.1 201 42 1500000 1 text/plain - 100% %z %{X}z trailing%`,
+		renderAccess(parseAccessTemplate(`%m %U %q %H %s %B %D %T %{Content-Type}o %{X-Missing}i 100%% %z %{X}z trailing%`), ai))
+
+	ai.bytes = 0
+	ai.rawQuery = ""
+	assert.Equal(t, `"GET /a/b HTTP/1.1" - 0 10.0.0.1`, renderAccess(parseAccessTemplate(`"%r" %b %B %a`), ai))
+}
+
+func TestHTTPLogging_FormatCommonAndCombined(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("hello")) })
+
+		h := HTTPLogging(ok, &HTTPLogOptions{Format: FormatCommon})
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/p?q=1", nil))
+		out := buf.String()
+		assert.NotContains(t, out, "\x1b[")
+		assert.Regexp(t, `\nINFO     192\.0\.2\.1 - - \[[^\]]+\] "GET /p\?q=1 HTTP/1\.1" 200 5\n$`, out)
+
+		buf.Reset()
+		h = HTTPLogging(ok, &HTTPLogOptions{Format: FormatCombined})
+		req := httptest.NewRequest(http.MethodGet, "/p", nil)
+		req.Header.Set("User-Agent", "ua/1")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Contains(t, buf.String(), `200 5 "-" "ua/1"`)
+
+		buf.Reset()
+		h = HTTPLogging(ok, &HTTPLogOptions{Format: FormatTemplate, Template: "%m %U %>s", Redactor: NewRedactor()})
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/t", nil))
+		assert.Contains(t, buf.String(), "INFO     POST /t 200\n")
+
+		buf.Reset()
+		h = HTTPLogging(ok, &HTTPLogOptions{Format: FormatTemplate, Redactor: NewRedactor()})
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/t?token=abc", nil))
+		assert.Contains(t, buf.String(), `"GET /t?token=[REDACTED] HTTP/1.1" 200 5`)
+	})
+}
+
+func TestAccessTemplateEscapesRequestValues(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/a%0Ab", nil)
+	req.Header.Set("User-Agent", "ua\" 200 5\nforged")
+	req.Header.Set("Referer", `C:\x`)
+	req.SetBasicAuth("bo\"b", "pw")
+	ai := &accessInfo{r: req, header: http.Header{"X-Out": {"é"}}, status: 200, clientIP: "10.0.0.1"}
+	assert.Equal(t, `/a\x0ab bo\"b "ua\" 200 5\x0aforged" "C:\\x" \xc3\xa9`,
+		renderAccess(parseAccessTemplate(`%U %u "%{User-Agent}i" "%{Referer}i" %{X-Out}o`), ai))
+}
+
+func TestHTTPLogging_AccessLogWriter(t *testing.T) {
+	var logged, access bytes.Buffer
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("hello")) }),
+		&HTTPLogOptions{Format: FormatCommon, Logger: New(&logged, "", LstdFlags), SkipRequestLine: true, AccessLog: &access})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/p", nil))
+	assert.Empty(t, logged.String())
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [-+]\d{4}\] "GET /p HTTP/1\.1" 200 5\n$`, access.String())
+}
+
+func TestHTTPLogging_FormatStructuredJSON(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(io.Discard)
+		AddHandler(LevelDebug, NewJSONHandler(&buf))
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}), &HTTPLogOptions{Format: FormatStructured, IncludeQuery: true})
+		req := httptest.NewRequest(http.MethodGet, "/missing?x=1", nil)
+		req.Header.Set("User-Agent", "ua/2")
+		req.Header.Set("Referer", "r")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 2)
+		assert.NotContains(t, buf.String(), `\u001b`)
+		var pre, access map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &pre))
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &access))
+		assert.Equal(t, "http request started", pre["msg"])
+		assert.Equal(t, "http request", access["msg"])
+		assert.Equal(t, "WARN    ", access["level"])
+		attrs := access["attrs"].(map[string]any)
+		assert.Equal(t, "GET", attrs["http.method"])
+		assert.Equal(t, "/missing", attrs["url.path"])
+		assert.Equal(t, "x=1", attrs["url.query"])
+		assert.Equal(t, float64(404), attrs["http.status_code"])
+		assert.Equal(t, "192.0.2.1", attrs["client.ip"])
+		assert.Equal(t, "ua/2", attrs["user_agent.original"])
+		assert.Equal(t, "r", attrs["http.referer"])
+	})
+}
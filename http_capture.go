@@ -0,0 +1,141 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxBodyBytes caps body previews when HTTPLogOptions.MaxBodyBytes is unset.
+const defaultMaxBodyBytes = 64 * 1024
+
+// sensitiveHeaders are always redacted when captured.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// DefaultBodyContentTypes are the media types previewed when
+// HTTPLogOptions.BodyContentTypes is empty, together with any "+json" or
+// "+xml" media type. Entries ending in "/" match a whole top-level type.
+var DefaultBodyContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+}
+
+// textualContentType reports whether a body with the given Content-Type header
+// may be previewed. An empty header is accepted so the caller can peek at the
+// body; previewable then decides from its first bytes.
+func textualContentType(header string, allowed []string) bool {
+	if header == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return false
+	}
+	if len(allowed) == 0 {
+		allowed = DefaultBodyContentTypes
+		if strings.HasSuffix(mt, "+json") || strings.HasSuffix(mt, "+xml") {
+			return true
+		}
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if (strings.HasSuffix(a, "/") && strings.HasPrefix(mt, a)) || mt == a {
+			return true
+		}
+	}
+	return false
+}
+
+// previewable reports whether a body starting with data may be previewed. A
+// body without a Content-Type is sniffed with http.DetectContentType, as
+// net/http does, so untyped binary data is skipped.
+func previewable(contentType string, data []byte, allowed []string) bool {
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return textualContentType(contentType, allowed)
+}
+
+// headerAttrs returns attrs for the allowlisted headers present in h, keyed
+// prefix+lower-case name. Credentials headers are redacted.
+func headerAttrs(prefix string, h http.Header, names []string) []any {
+	var attrs []any
+	for _, name := range names {
+		canon := http.CanonicalHeaderKey(name)
+		vals := h.Values(canon)
+		if len(vals) == 0 {
+			continue
+		}
+		v := strings.Join(vals, ", ")
+		if sensitiveHeaders[canon] {
+			v = RedactedText
+		}
+		attrs = append(attrs, prefix+strings.ToLower(canon), v)
+	}
+	return attrs
+}
+
+// peekBody reads up to limit bytes of body for a preview and returns a body
+// that replays them followed by the unread remainder, so the handler still sees
+// the full stream and nothing beyond the preview is buffered.
+func peekBody(body io.ReadCloser, limit int) (preview []byte, truncated bool, replay io.ReadCloser) {
+	data, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	truncated = len(data) > limit
+	replay = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), body), body}
+	if truncated {
+		data = data[:limit]
+	}
+	return data, truncated, replay
+}
+
+// bodyCapture records the first bytes of a response body for the access line.
+type bodyCapture struct {
+	limit     int
+	minStatus int
+	types     []string
+	buf       []byte
+	truncated bool
+	decided   bool
+	active    bool
+}
+
+// write copies up to the capture limit from b. It decides once, on the first
+// write, whether status and Content-Type qualify; see previewable.
+func (c *bodyCapture) write(status int, h http.Header, b []byte) {
+	if !c.decided {
+		c.decided = true
+		c.active = status >= c.minStatus && previewable(h.Get("Content-Type"), b, c.types)
+	}
+	if !c.active {
+		return
+	}
+	room := c.limit - len(c.buf)
+	if len(b) > room {
+		c.truncated = true
+		b = b[:room]
+	}
+	c.buf = append(c.buf, b...)
+}
+
+func (c *bodyCapture) preview() string {
+	if c == nil || len(c.buf) == 0 {
+		return ""
+	}
+	s := string(c.buf)
+	if c.truncated {
+		s += "…(truncated)"
+	}
+	return s
+}
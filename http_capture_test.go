@@ -0,0 +1,152 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextualContentType(t *testing.T) {
+	assert.True(t, textualContentType("", nil))
+	assert.True(t, textualContentType("application/json; charset=utf-8", nil))
+	assert.True(t, textualContentType("text/html", nil))
+	assert.True(t, textualContentType("application/problem+json", nil))
+	assert.False(t, textualContentType("image/png", nil))
+	assert.False(t, textualContentType("application/octet-stream", nil))
+	assert.False(t, textualContentType(";;bad", nil))
+	assert.True(t, textualContentType("image/png", []string{"image/"}))
+	assert.False(t, textualContentType("application/problem+json", []string{"text/plain"}))
+}
+
+func TestPeekBody_ReplaysFullStream(t *testing.T) {
+	body := io.NopCloser(strings.NewReader("0123456789"))
+	preview, truncated, replay := peekBody(body, 4)
+	assert.Equal(t, "0123", string(preview))
+	assert.True(t, truncated)
+	rest, _ := io.ReadAll(replay)
+	assert.Equal(t, "0123456789", string(rest))
+	assert.NoError(t, replay.Close())
+}
+
+func TestHTTPLogging_RequestBodyKeptIntactWhenTruncated(t *testing.T) {
+	var got string
+	var buf bytes.Buffer
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	}), &HTTPLogOptions{Mode: ColorOff, Logger: New(&buf, "", 0), LogPostBody: true, MaxBodyBytes: 3})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abcdef")))
+	assert.Equal(t, "abcdef", got)
+	assert.Contains(t, buf.String(), "body=abc…(truncated)")
+}
+
+func TestHTTPLogging_UntypedBinaryRequestBodySkipped(t *testing.T) {
+	var got string
+	var buf bytes.Buffer
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	}), &HTTPLogOptions{Mode: ColorOff, Logger: New(&buf, "", 0), LogPostBody: true})
+	bin := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(bin)))
+	assert.Equal(t, bin, got)
+	assert.NotContains(t, buf.String(), "body=")
+
+	buf.Reset()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("plain text")))
+	assert.Contains(t, buf.String(), "body=plain text")
+}
+
+func TestHTTPLogging_HeadersCapture(t *testing.T) {
+	var buf bytes.Buffer
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache", "HIT")
+		w.Header().Add("Set-Cookie", "sid=secret")
+	}), &HTTPLogOptions{
+		Mode:            ColorOff,
+		Logger:          New(&buf, "", 0),
+		RequestHeaders:  []string{"accept", "Authorization", "Cookie", "X-Missing"},
+		ResponseHeaders: []string{"X-Cache", "Set-Cookie"},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Cookie", "sid=secret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "req_header.accept=text/plain req_header.authorization=[REDACTED] req_header.cookie=[REDACTED]")
+	assert.NotContains(t, lines[0], "x-missing")
+	assert.Contains(t, lines[1], "resp_header.x-cache=HIT resp_header.set-cookie=[REDACTED]")
+	assert.NotContains(t, buf.String(), "secret")
+
+	// with the pre-request line disabled, request headers move to the access line
+	buf.Reset()
+	h = HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &HTTPLogOptions{
+		Mode: ColorOff, Logger: New(&buf, "", 0), SkipRequestLine: true, RequestHeaders: []string{"Accept"},
+	})
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, buf.String(), "status=200")
+	assert.Contains(t, buf.String(), "req_header.accept=text/plain")
+}
+
+func TestHTTPLogging_ResponseBodyPreview(t *testing.T) {
+	var buf bytes.Buffer
+	status, ctype, body := http.StatusBadRequest, "application/json", `{"error":"bad input","token":"t"}`
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ctype)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body[:10])
+		_, _ = io.WriteString(w, body[10:])
+	}), &HTTPLogOptions{Mode: ColorOff, Logger: New(&buf, "", 0), SkipRequestLine: true, LogResponseBody: true, Redactor: NewRedactor()})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, body, rr.Body.String())
+	assert.Contains(t, buf.String(), `resp_body={"error":"bad input","token":"[REDACTED]"}`)
+
+	// success responses are not captured
+	buf.Reset()
+	status = http.StatusOK
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotContains(t, buf.String(), "resp_body")
+
+	// binary content types are skipped
+	buf.Reset()
+	status, ctype = http.StatusInternalServerError, "application/octet-stream"
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotContains(t, buf.String(), "resp_body")
+
+	// without a Content-Type the body is sniffed and binary data skipped
+	buf.Reset()
+	ctype, body = "", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotContains(t, buf.String(), "resp_body")
+
+	// capped at MaxBodyBytes
+	buf.Reset()
+	h = HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "not found here")
+	}), &HTTPLogOptions{Mode: ColorOff, Logger: New(&buf, "", 0), SkipRequestLine: true, LogResponseBody: true, MaxBodyBytes: 9})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, buf.String(), "resp_body=not found…(truncated)")
+}
+
+func TestHTTPLogging_ResponseBodyPreviewViaReadFrom(t *testing.T) {
+	var buf bytes.Buffer
+	under := &readFromRW{}
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.(io.ReaderFrom).ReadFrom(strings.NewReader("upstream down"))
+	}), &HTTPLogOptions{Mode: ColorOff, Logger: New(&buf, "", 0), SkipRequestLine: true, LogResponseBody: true})
+	h.ServeHTTP(under, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "upstream down", under.body.String())
+	assert.Contains(t, buf.String(), "bytes=13")
+	assert.Contains(t, buf.String(), "resp_body=upstream down")
+}
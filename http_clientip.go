@@ -0,0 +1,107 @@
+package log
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultClientIPHeaders are consulted when the direct peer is a trusted proxy
+// and HTTPLogOptions.ClientIPHeaders is empty. Only X-Forwarded-For is used:
+// most proxies append to it but pass Forwarded and X-Real-IP from the client
+// through unchanged, so those are believed only when listed explicitly.
+var DefaultClientIPHeaders = []string{"X-Forwarded-For"}
+
+// clientIPResolver derives the originating client address from proxy headers,
+// believing them only when they were added by trusted proxies.
+type clientIPResolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+func (c *clientIPResolver) isTrusted(a netip.Addr) bool {
+	a = a.Unmap()
+	for _, p := range c.trusted {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the client IP for r. The remote address is returned unless
+// it belongs to a trusted proxy, in which case forwarding headers are walked
+// from the nearest hop outwards and the first untrusted address wins.
+func (c *clientIPResolver) resolve(r *http.Request) string {
+	remote := remoteHost(r)
+	addr, err := netip.ParseAddr(remote)
+	if err != nil || !c.isTrusted(addr) {
+		return remote
+	}
+	for _, h := range c.headers {
+		var hops []string
+		switch http.CanonicalHeaderKey(h) {
+		case "Forwarded":
+			hops = forwardedFor(r.Header.Values("Forwarded"))
+		case "X-Real-Ip":
+			if v := strings.TrimSpace(r.Header.Get(h)); v != "" {
+				hops = []string{v}
+			}
+		default: // X-Forwarded-For style comma-separated list
+			for _, v := range r.Header.Values(h) {
+				for _, p := range strings.Split(v, ",") {
+					hops = append(hops, strings.TrimSpace(p))
+				}
+			}
+		}
+		if len(hops) == 0 {
+			continue
+		}
+		var ip string
+		for i := len(hops) - 1; i >= 0; i-- {
+			a, ok := parseHop(hops[i])
+			if !ok {
+				break
+			}
+			ip = a.Unmap().String()
+			if !c.isTrusted(a) {
+				return ip
+			}
+		}
+		if ip != "" {
+			// every hop is trusted: the leftmost is the best we know
+			return ip
+		}
+	}
+	return remote
+}
+
+// forwardedFor extracts the for= parameters of RFC 7239 Forwarded headers.
+func forwardedFor(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(k, "for") {
+					out = append(out, strings.Trim(val, `"`))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// parseHop parses "ip", "ip:port", "[ipv6]" or "[ipv6]:port".
+func parseHop(s string) (netip.Addr, bool) {
+	if a, err := netip.ParseAddr(s); err == nil {
+		return a, true
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr(), true
+	}
+	if a, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")); err == nil {
+		return a, true
+	}
+	return netip.Addr{}, false
+}
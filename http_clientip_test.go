@@ -0,0 +1,94 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	c := &clientIPResolver{
+		trusted: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")},
+		headers: DefaultClientIPHeaders,
+	}
+	req := func(remote string, hdr ...string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remote
+		for i := 0; i+1 < len(hdr); i += 2 {
+			r.Header.Add(hdr[i], hdr[i+1])
+		}
+		return r
+	}
+
+	// untrusted peer: headers ignored
+	assert.Equal(t, "203.0.113.9", c.resolve(req("203.0.113.9:1000", "X-Forwarded-For", "1.2.3.4")))
+	// trusted peer without headers
+	assert.Equal(t, "10.1.1.1", c.resolve(req("10.1.1.1:1000")))
+	// X-Forwarded-For: rightmost untrusted hop wins, spoofed leftmost entries ignored
+	assert.Equal(t, "198.51.100.7", c.resolve(req("10.1.1.1:1000", "X-Forwarded-For", "6.6.6.6, 198.51.100.7, 10.2.2.2")))
+	// multiple header lines are concatenated
+	assert.Equal(t, "198.51.100.8", c.resolve(req("10.1.1.1:1000", "X-Forwarded-For", "6.6.6.6", "X-Forwarded-For", "198.51.100.8")))
+	// all hops trusted: leftmost
+	assert.Equal(t, "10.3.3.3", c.resolve(req("10.1.1.1:1000", "X-Forwarded-For", "10.3.3.3, 10.2.2.2")))
+	// a client-supplied Forwarded or X-Real-IP passed through by the proxy is
+	// not believed with the default headers
+	assert.Equal(t, "198.51.100.7", c.resolve(req("10.0.0.1:1000",
+		"Forwarded", "for=6.6.6.6",
+		"X-Real-IP", "6.6.6.6",
+		"X-Forwarded-For", "6.6.6.6, 198.51.100.7")))
+	assert.Equal(t, "10.0.0.1", c.resolve(req("10.0.0.1:1000", "Forwarded", "for=6.6.6.6", "X-Real-IP", "6.6.6.6")))
+
+	// explicitly listed headers, in order
+	c.headers = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}
+	// Forwarded supports quoting, ports and IPv6
+	assert.Equal(t, "2001:db8::1", c.resolve(req("10.1.1.1:1000",
+		"Forwarded", `for="[2001:db8::1]:4711";proto=https, for=10.2.2.2`,
+		"X-Forwarded-For", "198.51.100.7")))
+	assert.Equal(t, "192.0.2.60", c.resolve(req("[fd00::1]:1000", "Forwarded", "for=192.0.2.60:80;by=203.0.113.43")))
+	// X-Real-IP as a fallback
+	assert.Equal(t, "198.51.100.9", c.resolve(req("10.1.1.1:1000", "X-Real-IP", "198.51.100.9")))
+	// unparsable values fall back to the next header, then to the peer
+	assert.Equal(t, "198.51.100.9", c.resolve(req("10.1.1.1:1000", "Forwarded", "for=unknown", "X-Real-IP", "198.51.100.9")))
+	assert.Equal(t, "10.1.1.1", c.resolve(req("10.1.1.1:1000", "X-Forwarded-For", "garbage")))
+	// IPv4-mapped IPv6 peers are matched against IPv4 prefixes
+	assert.Equal(t, "198.51.100.1", c.resolve(req("[::ffff:10.1.1.1]:1000", "X-Real-IP", "198.51.100.1")))
+	// RemoteAddr without a port
+	assert.Equal(t, "203.0.113.9", c.resolve(req("203.0.113.9")))
+}
+
+func TestHTTPLogging_TrustedProxies(t *testing.T) {
+	var buf bytes.Buffer
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &HTTPLogOptions{
+		Mode:            ColorOff,
+		Logger:          New(&buf, "", 0),
+		SkipRequestLine: true,
+		TrustedProxies:  []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		ClientIPHeaders: []string{"X-Forwarded-For"},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil) // RemoteAddr 192.0.2.1:1234
+	req.Header.Set("X-Forwarded-For", "198.51.100.23")
+	req.Header.Set("X-Real-IP", "6.6.6.6")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, buf.String(), "GET 192.0.2.1:1234 /")
+	assert.Contains(t, buf.String(), "client_ip=198.51.100.23")
+
+	buf.Reset()
+	h = HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &HTTPLogOptions{
+		Logger:         New(&buf, "", 0),
+		Format:         FormatTemplate,
+		Template:       "%h",
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+	})
+	req.Header.Del("X-Forwarded-For")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, buf.String(), "INFO     192.0.2.1\n", "X-Real-IP is not believed by default")
+
+	buf.Reset()
+	req.Header.Set("X-Forwarded-For", "6.6.6.6, 198.51.100.23")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Contains(t, buf.String(), "INFO     198.51.100.23\n")
+}
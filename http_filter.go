@@ -0,0 +1,103 @@
+package log
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// StatusRange is an inclusive range of HTTP status codes, e.g. {200, 299}.
+type StatusRange struct {
+	Min, Max int
+}
+
+func (s StatusRange) contains(status int) bool { return status >= s.Min && status <= s.Max }
+
+// RouteToggle overrides a boolean HTTPLogOptions setting for a route.
+type RouteToggle int
+
+const (
+	RouteInherit RouteToggle = iota // keep the global setting
+	RouteOn                         // force on for this route
+	RouteOff                        // force off for this route
+)
+
+func (t RouteToggle) apply(v bool) bool {
+	switch t {
+	case RouteOn:
+		return true
+	case RouteOff:
+		return false
+	}
+	return v
+}
+
+// HTTPRoute overrides HTTPLogging behavior for requests whose path matches Path.
+// The first matching route wins.
+type HTTPRoute struct {
+	// Path is a path prefix ("/admin" matches "/admin" and "/admin/users"
+	// but not "/administrator"), a prefix wildcard ("/admin/*") or a
+	// path.Match glob ("/api/*/debug").
+	Path string
+	// Skip disables logging for the route.
+	Skip bool
+	// LogBody overrides LogPostBody for the route.
+	LogBody RouteToggle
+}
+
+// matchPath reports whether p matches pattern as described on HTTPRoute.Path.
+func matchPath(pattern, p string) bool {
+	if pattern == "" {
+		return false
+	}
+	if !strings.ContainsAny(pattern, "*?[\\") {
+		rest, ok := strings.CutPrefix(p, pattern)
+		return ok && (rest == "" || rest[0] == '/' || strings.HasSuffix(pattern, "/"))
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && !strings.ContainsAny(prefix, "*?[\\") {
+		return strings.HasPrefix(p, prefix)
+	}
+	ok, _ := path.Match(pattern, p)
+	return ok
+}
+
+// route returns the first route matching r, if any.
+func (o *HTTPLogOptions) route(r *http.Request) (HTTPRoute, bool) {
+	for _, rt := range o.Routes {
+		if matchPath(rt.Path, r.URL.Path) {
+			return rt, true
+		}
+	}
+	return HTTPRoute{}, false
+}
+
+// skipRequest reports whether r is excluded from logging before it is served.
+func (o *HTTPLogOptions) skipRequest(r *http.Request, rt HTTPRoute) bool {
+	if rt.Skip {
+		return true
+	}
+	for _, p := range o.SkipPaths {
+		if matchPath(p, r.URL.Path) {
+			return true
+		}
+	}
+	if o.SkipPathRegexp != nil && o.SkipPathRegexp.MatchString(r.URL.Path) {
+		return true
+	}
+	for _, m := range o.SkipMethods {
+		if strings.EqualFold(m, r.Method) {
+			return true
+		}
+	}
+	return o.Skip != nil && o.Skip(r)
+}
+
+// skipStatus reports whether the access line for status is suppressed.
+func (o *HTTPLogOptions) skipStatus(status int) bool {
+	for _, sr := range o.SkipStatus {
+		if sr.contains(status) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPath(t *testing.T) {
+	assert.True(t, matchPath("/metrics", "/metrics"))
+	assert.True(t, matchPath("/admin/", "/admin/users"))
+	assert.True(t, matchPath("/admin/*", "/admin/users/1"))
+	assert.False(t, matchPath("/admin/*", "/administrator"))
+	assert.True(t, matchPath("/api/*/debug", "/api/v1/debug"))
+	assert.False(t, matchPath("/api/*/debug", "/api/v1/x/debug"))
+	assert.False(t, matchPath("", "/x"))
+	assert.False(t, matchPath("/login", "/"))
+	assert.True(t, matchPath("/login", "/login/sso"))
+	assert.False(t, matchPath("/login", "/login-help"))
+	assert.False(t, matchPath("/healthz", "/healthzfoo"))
+	assert.False(t, matchPath("/admin/", "/administrator"))
+}
+
+func TestHTTPLogging_SkipRules(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	status := http.StatusOK
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}), &HTTPLogOptions{
+		Mode:           ColorOff,
+		Logger:         l,
+		SkipPaths:      []string{"/healthz", "/static/*"},
+		SkipPathRegexp: regexp.MustCompile(`^/metrics$`),
+		SkipMethods:    []string{"options"},
+		Skip:           func(r *http.Request) bool { return r.Header.Get("X-Probe") != "" },
+		SkipStatus:     []StatusRange{{Min: 300, Max: 399}},
+	})
+	serve := func(method, target string, hdr ...string) {
+		req := httptest.NewRequest(method, target, nil)
+		if len(hdr) == 2 {
+			req.Header.Set(hdr[0], hdr[1])
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve(http.MethodGet, "/healthz")
+	serve(http.MethodGet, "/static/app.js")
+	serve(http.MethodGet, "/metrics")
+	serve(http.MethodOptions, "/api")
+	serve(http.MethodGet, "/api", "X-Probe", "1")
+	assert.Empty(t, buf.String())
+
+	// status-based skip only drops the access line
+	status = http.StatusFound
+	serve(http.MethodGet, "/api")
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), "DEBUG    GET")
+
+	buf.Reset()
+	status = http.StatusOK
+	serve(http.MethodGet, "/metrics/extra")
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+	buf.Reset()
+	serve(http.MethodGet, "/healthzfoo")
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"), "skip paths end at a segment boundary")
+}
+
+func TestHTTPLogging_RouteBodyOverrides(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &HTTPLogOptions{
+		Mode:        ColorOff,
+		Logger:      l,
+		LogPostBody: true,
+		Routes: []HTTPRoute{
+			{Path: "/login", LogBody: RouteOff},
+			{Path: "/internal/*", Skip: true},
+		},
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("pw=1")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("qty=2")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/internal/x", strings.NewReader("z")))
+	out := buf.String()
+	assert.NotContains(t, out, "pw=1")
+	assert.Contains(t, out, "body=qty=2")
+	assert.NotContains(t, out, "/internal")
+
+	buf.Reset()
+	h = HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), &HTTPLogOptions{
+		Mode:   ColorOff,
+		Logger: l,
+		Routes: []HTTPRoute{{Path: "/admin/*", LogBody: RouteOn}},
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/admin/users", strings.NewReader("name=a")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name=b")))
+	assert.Contains(t, buf.String(), "body=name=a")
+	assert.NotContains(t, buf.String(), "name=b")
+}
+
+func TestHTTPLogging_SlowThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}), &HTTPLogOptions{Mode: ColorOff, Logger: l, SkipRequestLine: true, SlowThreshold: 10 * time.Millisecond})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "INFO "), lines[0])
+	assert.NotContains(t, lines[0], "slow=true")
+	assert.True(t, strings.HasPrefix(lines[1], "WARN "), lines[1])
+	assert.Contains(t, lines[1], "slow=true")
+}
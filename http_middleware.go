@@ -1,10 +1,14 @@
 package log
 
 import (
-	"bytes"
+	"fmt"
 	"io"
 	"net/http"
+	"net/netip"
 	"os"
+	"regexp"
+	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/mattn/go-isatty"
@@ -20,8 +24,69 @@ const (
 	ansiMagenta = "\x1b[35m"
 )
 
+// HTTPLevels sets the levels HTTPLogging logs at.
+type HTTPLevels struct {
+	Request     Level // pre-request line
+	Success     Level // 1xx and 2xx access lines
+	Redirect    Level // 3xx access lines
+	ClientError Level // 4xx access lines
+	ServerError Level // 5xx access lines
+}
+
+// DefaultHTTPLevels returns the levels used when HTTPLogOptions.Levels is nil:
+// DEBUG for the pre-request line, INFO below 400, WARN for 4xx and ERROR for 5xx.
+func DefaultHTTPLevels() HTTPLevels {
+	return HTTPLevels{
+		Request:     LevelDebug,
+		Success:     LevelInfo,
+		Redirect:    LevelInfo,
+		ClientError: LevelWarn,
+		ServerError: LevelError,
+	}
+}
+
+func (lv *HTTPLevels) forStatus(status int) Level {
+	switch {
+	case status >= 500:
+		return lv.ServerError
+	case status >= 400:
+		return lv.ClientError
+	case status >= 300:
+		return lv.Redirect
+	default:
+		return lv.Success
+	}
+}
+
 // HTTPLogOptions customizes HTTPLogging middleware.
 type HTTPLogOptions struct {
+	// Logger receives the log lines (default: the package default logger).
+	// It is also stored in the request context for FromContext.
+	Logger *Logger
+	// Levels overrides the levels of the pre-request and access lines
+	// (default DefaultHTTPLevels()).
+	Levels *HTTPLevels
+	// StatusLevel, when set, maps the response status to the access line level
+	// and takes precedence over Levels.
+	StatusLevel func(status int) Level
+	// SkipRequestLine disables the pre-request line.
+	SkipRequestLine bool
+	// SkipPaths excludes requests whose path matches any entry (same syntax as
+	// HTTPRoute.Path), e.g. "/healthz" or "/metrics".
+	SkipPaths []string
+	// SkipPathRegexp excludes requests whose path matches the expression.
+	SkipPathRegexp *regexp.Regexp
+	// SkipMethods excludes requests by method (case-insensitive), e.g. "OPTIONS".
+	SkipMethods []string
+	// Skip excludes requests for which it returns true.
+	Skip func(r *http.Request) bool
+	// SkipStatus suppresses the access line when the status falls in any range.
+	SkipStatus []StatusRange
+	// Routes holds per-route overrides; the first matching route applies.
+	Routes []HTTPRoute
+	// SlowThreshold escalates the access line to at least WARN, with slow=true,
+	// when the request takes longer. Zero disables it.
+	SlowThreshold time.Duration
 	// Color mode for method/path highlighting.
 	Mode ColorMode
 	// Include query string when building the request path display.
@@ -30,6 +95,60 @@ type HTTPLogOptions struct {
 	LogPostBody bool
 	// MaxBodyBytes caps the size of the logged body (default 64KB when zero or negative).
 	MaxBodyBytes int
+	// LogResponseBody adds a preview of the response body (capped at MaxBodyBytes)
+	// to the access line when the status is at least ResponseBodyMinStatus.
+	LogResponseBody bool
+	// ResponseBodyMinStatus is the lowest status whose body is previewed (default 400).
+	ResponseBodyMinStatus int
+	// BodyContentTypes restricts request and response body previews to these
+	// media types (default DefaultBodyContentTypes) so binary payloads are skipped.
+	BodyContentTypes []string
+	// RequestHeaders lists request headers logged as req_header.<name> attrs.
+	// Authorization, Proxy-Authorization and Cookie are always redacted.
+	RequestHeaders []string
+	// ResponseHeaders lists response headers logged as resp_header.<name> attrs
+	// on the access line. Set-Cookie is always redacted.
+	ResponseHeaders []string
+	// Redactor, when set, scrubs the query string and body preview before logging.
+	Redactor *Redactor
+	// RequestID enables request IDs: the incoming RequestIDHeader is reused (or a
+	// new id generated), echoed on the response, attached as request_id to both
+	// log lines, and stored in the request context (see RequestIDFromContext and
+	// FromContext) so downstream logs carry it too.
+	RequestID bool
+	// RequestIDHeader is the header read and set for request IDs (default "X-Request-ID").
+	RequestIDHeader string
+	// GenerateRequestID creates ids for requests without one (default NewRequestID).
+	GenerateRequestID func() string
+	// RecoverPanics recovers panics raised by the wrapped handler, logs them at
+	// CRITICAL with the stack trace, writes PanicHandler's response when nothing
+	// has been sent yet, and still writes the access line.
+	RecoverPanics bool
+	// PanicHandler writes the response after a recovered panic (default: plain 500).
+	PanicHandler http.Handler
+	// RepanicAbort re-panics http.ErrAbortHandler after logging so net/http
+	// aborts the connection as the handler intended.
+	RepanicAbort bool
+	// Format selects the access line rendering (default: colored method/path
+	// message with attrs). Colors are only applied with FormatDefault.
+	Format AccessLogFormat
+	// Template is the access line template used with FormatTemplate, in Apache
+	// mod_log_config syntax, e.g. `%h %t "%r" %>s %b %D` (default CommonLogTemplate).
+	Template string
+	// AccessLog, when set, receives the access lines of FormatCommon,
+	// FormatCombined and FormatTemplate verbatim, one per line, instead of the
+	// logger, so the output is plain CLF that existing tools can parse. Without
+	// it the line is logged as a record message and handlers add their own
+	// time and level in front of it.
+	AccessLog io.Writer
+	// TrustedProxies lists the proxy networks whose forwarding headers are
+	// believed. When set, the resolved client address is logged as client_ip
+	// (client.ip in FormatStructured, %h in templates) next to the raw remote address.
+	TrustedProxies []netip.Prefix
+	// ClientIPHeaders are the forwarding headers consulted, in order
+	// (default DefaultClientIPHeaders). List Forwarded or X-Real-IP only when
+	// your proxies set or strip them.
+	ClientIPHeaders []string
 }
 
 func (o *HTTPLogOptions) enabled() bool {
@@ -74,6 +193,7 @@ func methodColor(method string) string {
 
 // HTTPLogging returns middleware that logs a colored pre-request line (method/path)
 // at DEBUG and a colored access line at INFO/WARN/ERROR according to status.
+// Levels and the destination Logger are configurable through opts.
 // It highlights only the method and path tokens; other parts follow the logger handler's coloring.
 func HTTPLogging(next http.Handler, opts *HTTPLogOptions) http.Handler {
 	var o HTTPLogOptions
@@ -86,45 +206,164 @@ func HTTPLogging(next http.Handler, opts *HTTPLogOptions) http.Handler {
 	} else {
 		o.IncludeQuery = true
 	}
-	colorOn := o.enabled()
+	lg := o.Logger
+	if lg == nil {
+		lg = std
+	}
+	levels := DefaultHTTPLevels()
+	if o.Levels != nil {
+		levels = *o.Levels
+	}
+	if o.StatusLevel == nil {
+		o.StatusLevel = levels.forStatus
+	}
+	if o.MaxBodyBytes <= 0 {
+		o.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if o.ResponseBodyMinStatus <= 0 {
+		o.ResponseBodyMinStatus = http.StatusBadRequest
+	}
+	if o.RequestIDHeader == "" {
+		o.RequestIDHeader = "X-Request-ID"
+	}
+	if o.GenerateRequestID == nil {
+		o.GenerateRequestID = NewRequestID
+	}
+	if o.PanicHandler == nil {
+		o.PanicHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		})
+	}
+	var ips *clientIPResolver
+	if len(o.TrustedProxies) > 0 {
+		ips = &clientIPResolver{trusted: o.TrustedProxies, headers: o.ClientIPHeaders}
+		if len(ips.headers) == 0 {
+			ips.headers = DefaultClientIPHeaders
+		}
+	}
+	colorOn := o.Format == FormatDefault && o.enabled()
+	var tmpl []accessToken
+	switch o.Format {
+	case FormatCommon:
+		tmpl = parseAccessTemplate(CommonLogTemplate)
+	case FormatCombined:
+		tmpl = parseAccessTemplate(CombinedLogTemplate)
+	case FormatTemplate:
+		if o.Template == "" {
+			o.Template = CommonLogTemplate
+		}
+		tmpl = parseAccessTemplate(o.Template)
+	}
+	var accessMu sync.Mutex // serializes writes to o.AccessLog
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		rt, _ := o.route(r)
+		quiet := o.skipRequest(r, rt)
+		var reqID string
+		if o.RequestID {
+			reqID = r.Header.Get(o.RequestIDHeader)
+			if !validRequestID(reqID) {
+				reqID = o.GenerateRequestID()
+			}
+			w.Header().Set(o.RequestIDHeader, reqID)
+			base := o.Logger
+			if base == nil {
+				base = FromContext(r.Context())
+			}
+			ctx := ContextWithRequestID(r.Context(), reqID)
+			ctx = NewContext(ctx, base.With("request_id", reqID))
+			r = r.WithContext(ctx)
+		} else if o.Logger != nil {
+			r = r.WithContext(NewContext(r.Context(), o.Logger))
+		}
 		// Build display path
 		dispPath := r.URL.Path
+		var query string
 		if o.IncludeQuery && r.URL.RawQuery != "" {
-			dispPath += "?" + r.URL.RawQuery
+			query = r.URL.RawQuery
+			if o.Redactor != nil {
+				query = o.Redactor.RedactQuery(query)
+			}
+			dispPath += "?" + query
+		}
+		clientIP := remoteHost(r)
+		if ips != nil {
+			clientIP = ips.resolve(r)
 		}
 		// Optionally read and log body (preview) for mutation methods and restore body for handler.
 		var bodyPreview string
-		if o.LogPostBody && (r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch) && r.Body != nil {
-			limit := int64(64 * 1024)
-			if o.MaxBodyBytes > 0 {
-				limit = int64(o.MaxBodyBytes)
-			}
-			data, _ := io.ReadAll(io.LimitReader(r.Body, limit+1))
-			truncated := int64(len(data)) > limit
-			if truncated {
-				data = data[:limit]
-			}
-			_ = r.Body.Close()
-			r.Body = io.NopCloser(bytes.NewReader(data))
-			bodyPreview = string(data)
-			if truncated {
-				bodyPreview += "…(truncated)"
+		if !quiet && rt.LogBody.apply(o.LogPostBody) && (r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch) &&
+			r.Body != nil && textualContentType(r.Header.Get("Content-Type"), o.BodyContentTypes) {
+			data, truncated, replay := peekBody(r.Body, o.MaxBodyBytes)
+			r.Body = replay
+			if previewable(r.Header.Get("Content-Type"), data, o.BodyContentTypes) {
+				bodyPreview = string(data)
+				if o.Redactor != nil {
+					bodyPreview = o.Redactor.RedactBody(bodyPreview)
+				}
+				if truncated {
+					bodyPreview += "…(truncated)"
+				}
 			}
 		}
 		// Pre-request line with highlighted method and path in the message
 		msg := colorWrap(r.Method, methodColor(r.Method), colorOn) + " " + r.RemoteAddr + " " + colorWrap(dispPath, ansiBold, colorOn)
 		attrs := []any{"ua", r.UserAgent()}
+		if o.Format == FormatStructured {
+			msg = "http request started"
+			attrs = []any{"http.method", r.Method, "url.path", r.URL.Path}
+			if query != "" {
+				attrs = append(attrs, "url.query", query)
+			}
+			attrs = append(attrs, "client.ip", clientIP, "user_agent.original", r.UserAgent())
+		}
+		if reqID != "" {
+			attrs = append(attrs, "request_id", reqID)
+		}
+		reqHeaders := headerAttrs("req_header.", r.Header, o.RequestHeaders)
+		if !o.SkipRequestLine {
+			attrs = append(attrs, reqHeaders...)
+		}
 		if bodyPreview != "" {
 			attrs = append(attrs, "body", bodyPreview)
 		}
-		Debug(msg, attrs...)
+		if !quiet && !o.SkipRequestLine {
+			lg.Log(levels.Request, msg, attrs...)
+		}
 
 		// Wrap writer to capture status/bytes
 		wrapper := &httpLogRW{ResponseWriter: w}
-		next.ServeHTTP(wrapper, r)
+		if o.LogResponseBody && !quiet {
+			wrapper.capture = &bodyCapture{limit: o.MaxBodyBytes, minStatus: o.ResponseBodyMinStatus, types: o.BodyContentTypes}
+		}
+		ww := wrapper.wrapped()
+		var recovered any
+		var stack []byte
+		func() {
+			if o.RecoverPanics {
+				defer func() {
+					if recovered = recover(); recovered != nil {
+						stack = debug.Stack()
+					}
+				}()
+			}
+			next.ServeHTTP(ww, r)
+		}()
+		if recovered != nil {
+			panicAttrs := []any{"method", r.Method, "path", r.URL.Path, "duration", time.Since(start).String()}
+			if reqID != "" {
+				panicAttrs = append(panicAttrs, "request_id", reqID)
+			}
+			panicAttrs = append(panicAttrs, "stack", StackTrace(stack))
+			lg.Critical(fmt.Sprintf("panic: %v", recovered), panicAttrs...)
+			if wrapper.status == 0 {
+				o.PanicHandler.ServeHTTP(ww, r)
+				if wrapper.status == 0 {
+					wrapper.status = http.StatusInternalServerError
+				}
+			}
+		}
 
 		dur := time.Since(start)
 		status := wrapper.status
@@ -133,35 +372,66 @@ func HTTPLogging(next http.Handler, opts *HTTPLogOptions) http.Handler {
 		}
 		// Access line; message shows colored method/path again
 		msg2 := colorWrap(r.Method, methodColor(r.Method), colorOn) + " " + r.RemoteAddr + " " + colorWrap(dispPath, ansiBold, colorOn)
-		accessAttrs := []any{"status", status, "bytes", wrapper.bytes, "duration", dur.String()}
+		accessAttrs := []any{"status", status, "bytes", wrapper.written(), "duration", dur.String()}
+		if ips != nil {
+			accessAttrs = append(accessAttrs, "client_ip", clientIP)
+		}
+		ai := &accessInfo{r: r, header: w.Header(), start: start, dur: dur, status: status, bytes: wrapper.written(), clientIP: clientIP, rawQuery: r.URL.RawQuery}
+		if o.Redactor != nil {
+			ai.rawQuery = o.Redactor.RedactQuery(ai.rawQuery)
+		}
+		switch {
+		case o.Format == FormatStructured:
+			msg2 = "http request"
+			accessAttrs = structuredAccessAttrs(ai, query)
+		case tmpl != nil:
+			msg2 = renderAccess(tmpl, ai)
+			accessAttrs = nil
+		}
+		if reqID != "" {
+			accessAttrs = append(accessAttrs, "request_id", reqID)
+		}
+		if o.SkipRequestLine {
+			accessAttrs = append(accessAttrs, reqHeaders...)
+		}
+		accessAttrs = append(accessAttrs, headerAttrs("resp_header.", w.Header(), o.ResponseHeaders)...)
+		if rb := wrapper.capture.preview(); rb != "" {
+			if o.Redactor != nil {
+				rb = o.Redactor.RedactBody(rb)
+			}
+			accessAttrs = append(accessAttrs, "resp_body", rb)
+		}
+		level := o.StatusLevel(status)
+		if o.SlowThreshold > 0 && dur > o.SlowThreshold {
+			if level < LevelWarn {
+				level = LevelWarn
+			}
+			accessAttrs = append(accessAttrs, "slow", true)
+		}
 		switch {
-		case status >= 500:
-			Error(msg2, accessAttrs...)
-		case status >= 400:
-			Warn(msg2, accessAttrs...)
+		case quiet || o.skipStatus(status):
+		case tmpl != nil && o.AccessLog != nil:
+			accessMu.Lock()
+			_, _ = io.WriteString(o.AccessLog, msg2+"\n")
+			accessMu.Unlock()
 		default:
-			Info(msg2, accessAttrs...)
+			lg.Log(level, msg2, accessAttrs...)
+		}
+		if recovered == http.ErrAbortHandler && o.RepanicAbort {
+			panic(recovered)
 		}
 	})
 }
 
-// httpLogRW is a small ResponseWriter wrapper used by HTTPLogging.
-type httpLogRW struct {
-	http.ResponseWriter
-	status int
-	bytes  int
-}
-
-func (w *httpLogRW) WriteHeader(code int) {
-	w.status = code
-	w.ResponseWriter.WriteHeader(code)
-}
-
-func (w *httpLogRW) Write(b []byte) (int, error) {
-	if w.status == 0 {
-		w.status = http.StatusOK
+// validRequestID accepts incoming ids of reasonable length made of printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
 	}
-	n, err := w.ResponseWriter.Write(b)
-	w.bytes += n
-	return n, err
+	return true
 }
@@ -2,11 +2,14 @@ package log
 
 import (
 	"bytes"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHTTPLogging_Info_Warn_Error_and_Query(t *testing.T) {
@@ -214,3 +217,179 @@ func TestHTTPLogging_StatusDefaultOKWhenNoWrite(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTPLogging_RequestID(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		var downstream string
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			downstream = RequestIDFromContext(r.Context())
+			FromContext(r.Context()).Info("inside")
+		}), &HTTPLogOptions{Mode: ColorOff, RequestID: true, GenerateRequestID: func() string { return "gen-1" }})
+
+		// generated when absent
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/r", nil))
+		assert.Equal(t, "gen-1", rr.Header().Get("X-Request-ID"))
+		assert.Equal(t, "gen-1", downstream)
+		assert.Equal(t, 3, strings.Count(buf.String(), "request_id=gen-1"))
+		assert.Contains(t, buf.String(), "inside request_id=gen-1")
+
+		// reused when present and valid
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/r", nil)
+		req.Header.Set("X-Request-ID", "incoming-42")
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, "incoming-42", rr.Header().Get("X-Request-ID"))
+		assert.Equal(t, 3, strings.Count(buf.String(), "request_id=incoming-42"))
+
+		// invalid ids are replaced
+		req = httptest.NewRequest(http.MethodGet, "/r", nil)
+		req.Header.Set("X-Request-ID", "bad id\x01")
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, "gen-1", rr.Header().Get("X-Request-ID"))
+	})
+}
+
+func TestHTTPLogging_RequestIDCustomHeader(t *testing.T) {
+	withStdReset(t, func() {
+		SetOutput(io.Discard)
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			&HTTPLogOptions{RequestID: true, RequestIDHeader: "X-Correlation-ID"})
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Len(t, rr.Header().Get("X-Correlation-ID"), 36)
+		assert.Empty(t, rr.Header().Get("X-Request-ID"))
+	})
+}
+
+func TestHTTPLogging_RecoverPanics(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("kaboom")
+		}), &HTTPLogOptions{Mode: ColorOff, RecoverPanics: true, RequestID: true, GenerateRequestID: func() string { return "rid" }})
+		rr := httptest.NewRecorder()
+		assert.NotPanics(t, func() { h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/p", nil)) })
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		out := buf.String()
+		assert.Contains(t, out, "CRITICAL panic: kaboom method=GET path=/p duration=")
+		assert.Contains(t, out, "request_id=rid stack=goroutine")
+		assert.Contains(t, out, "TestHTTPLogging_RecoverPanics")
+		assert.Contains(t, out, "ERROR    GET 192.0.2.1:1234 /p status=500")
+	})
+}
+
+func TestHTTPLogging_RecoverPanics_HeadersSentAndCustomResponse(t *testing.T) {
+	withStdReset(t, func() {
+		SetOutput(io.Discard)
+		// headers already sent: response left untouched
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("late")
+		}), &HTTPLogOptions{RecoverPanics: true})
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+
+		h2 := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("early")
+		}), &HTTPLogOptions{RecoverPanics: true, PanicHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("sorry"))
+		})})
+		rr2 := httptest.NewRecorder()
+		h2.ServeHTTP(rr2, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rr2.Code)
+		assert.Equal(t, "sorry", rr2.Body.String())
+	})
+}
+
+func TestHTTPLogging_PanicsPropagate(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		abort := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) })
+
+		// without recovery the panic escapes
+		h := HTTPLogging(abort, &HTTPLogOptions{})
+		assert.Panics(t, func() { h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) })
+
+		// ErrAbortHandler is logged then re-panicked when requested
+		buf.Reset()
+		h = HTTPLogging(abort, &HTTPLogOptions{RecoverPanics: true, RepanicAbort: true})
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		assert.Contains(t, buf.String(), "CRITICAL")
+
+		h = HTTPLogging(abort, &HTTPLogOptions{RecoverPanics: true})
+		assert.NotPanics(t, func() { h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) })
+	})
+}
+
+func TestHTTPLogging_CustomLoggerAndLevels(t *testing.T) {
+	withStdReset(t, func() {
+		var stdBuf, buf bytes.Buffer
+		SetOutput(&stdBuf)
+		l := New(&buf, "svc", 0)
+		var ctxLogger *Logger
+		status := http.StatusOK
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctxLogger = FromContext(r.Context())
+			w.WriteHeader(status)
+		}), &HTTPLogOptions{
+			Mode:   ColorOff,
+			Logger: l,
+			Levels: &HTTPLevels{Request: LevelDetail, Success: LevelNotice, Redirect: LevelInfo, ClientError: LevelError, ServerError: LevelAlert},
+		})
+		for _, status = range []int{200, 302, 404, 503} {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/x", nil))
+		}
+		assert.Empty(t, stdBuf.String())
+		assert.Same(t, l, ctxLogger)
+		out := buf.String()
+		assert.Equal(t, 4, strings.Count(out, "DETAIL   [svc] GET"))
+		assert.Contains(t, out, "NOTICE   [svc] GET 192.0.2.1:1234 /x status=200")
+		assert.Contains(t, out, "INFO     [svc] GET 192.0.2.1:1234 /x status=302")
+		assert.Contains(t, out, "ERROR    [svc] GET 192.0.2.1:1234 /x status=404")
+		assert.Contains(t, out, "ALERT    [svc] GET 192.0.2.1:1234 /x status=503")
+	})
+}
+
+func TestHTTPLogging_StatusLevelAndSkipRequestLine(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}), &HTTPLogOptions{
+		Mode:            ColorOff,
+		Logger:          l,
+		SkipRequestLine: true,
+		StatusLevel: func(status int) Level {
+			if status == http.StatusNotFound {
+				return LevelDebug
+			}
+			return LevelInfo
+		},
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nf", nil))
+	assert.True(t, strings.HasPrefix(buf.String(), "DEBUG    GET 192.0.2.1:1234 /nf status=404 bytes=0 duration="), buf.String())
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+}
+
+func TestHTTPLogging_CustomLoggerCarriesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "", 0)
+	h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("inner")
+	}), &HTTPLogOptions{Logger: l, RequestID: true, SkipRequestLine: true, GenerateRequestID: func() string { return "id9" }})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, buf.String(), "INFO     inner request_id=id9\n")
+}
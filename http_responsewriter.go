@@ -0,0 +1,228 @@
+package log
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// httpLogRW is a small ResponseWriter wrapper used by HTTPLogging to capture the
+// status code and the number of bytes written. Use wrapped to obtain a writer
+// exposing the same optional interfaces as the underlying one.
+type httpLogRW struct {
+	http.ResponseWriter
+	status   int
+	bytes    int64
+	hijacked bool
+	conn     *countingConn // set once hijacked
+	capture  *bodyCapture  // response body preview, when enabled
+}
+
+// WriteHeader records the first final status; informational 1xx codes other
+// than 101 Switching Protocols may precede it and are not recorded.
+func (w *httpLogRW) WriteHeader(code int) {
+	if w.status == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *httpLogRW) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	if w.capture != nil {
+		w.capture.write(w.status, w.Header(), b[:n])
+	}
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *httpLogRW) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// written reports the bytes sent through Write, ReadFrom and, after a hijack,
+// the hijacked connection.
+func (w *httpLogRW) written() int64 {
+	if w.conn != nil {
+		return w.bytes + w.conn.n.Load()
+	}
+	return w.bytes
+}
+
+type rwFlusher struct{ w *httpLogRW }
+
+func (f rwFlusher) Flush() {
+	if f.w.status == 0 {
+		f.w.status = http.StatusOK
+	}
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type rwHijacker struct{ w *httpLogRW }
+
+func (h rwHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	c, brw, err := h.w.ResponseWriter.(http.Hijacker).Hijack()
+	if err != nil {
+		return c, brw, err
+	}
+	h.w.hijacked = true
+	if h.w.status == 0 {
+		h.w.status = http.StatusSwitchingProtocols
+	}
+	cc := &countingConn{Conn: c}
+	h.w.conn = cc
+	brw = bufio.NewReadWriter(brw.Reader, bufio.NewWriterSize(cc, brw.Writer.Size()))
+	return cc, brw, nil
+}
+
+type rwPusher struct{ w *httpLogRW }
+
+func (p rwPusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type rwReaderFrom struct{ w *httpLogRW }
+
+func (r rwReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	if r.w.status == 0 {
+		r.w.status = http.StatusOK
+	}
+	if r.w.capture != nil && r.w.status >= r.w.capture.minStatus {
+		// Go through Write so the preview sees the data.
+		return io.Copy(struct{ io.Writer }{r.w}, src)
+	}
+	n, err := r.w.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.w.bytes += n
+	return n, err
+}
+
+// countingConn counts bytes written to a hijacked connection.
+type countingConn struct {
+	net.Conn
+	n atomic.Int64
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// wrapped returns w combined with exactly the optional interfaces
+// (http.Flusher, http.Hijacker, http.Pusher, io.ReaderFrom) implemented by the
+// underlying ResponseWriter.
+func (w *httpLogRW) wrapped() http.ResponseWriter {
+	var mask int
+	if _, ok := w.ResponseWriter.(http.Flusher); ok {
+		mask |= 1
+	}
+	if _, ok := w.ResponseWriter.(http.Hijacker); ok {
+		mask |= 2
+	}
+	if _, ok := w.ResponseWriter.(http.Pusher); ok {
+		mask |= 4
+	}
+	if _, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		mask |= 8
+	}
+	f, h, p, rf := rwFlusher{w}, rwHijacker{w}, rwPusher{w}, rwReaderFrom{w}
+	switch mask {
+	case 1:
+		return struct {
+			*httpLogRW
+			rwFlusher
+		}{w, f}
+	case 2:
+		return struct {
+			*httpLogRW
+			rwHijacker
+		}{w, h}
+	case 3:
+		return struct {
+			*httpLogRW
+			rwFlusher
+			rwHijacker
+		}{w, f, h}
+	case 4:
+		return struct {
+			*httpLogRW
+			rwPusher
+		}{w, p}
+	case 5:
+		return struct {
+			*httpLogRW
+			rwFlusher
+			rwPusher
+		}{w, f, p}
+	case 6:
+		return struct {
+			*httpLogRW
+			rwHijacker
+			rwPusher
+		}{w, h, p}
+	case 7:
+		return struct {
+			*httpLogRW
+			rwFlusher
+			rwHijacker
+			rwPusher
+		}{w, f, h, p}
+	case 8:
+		return struct {
+			*httpLogRW
+			rwReaderFrom
+		}{w, rf}
+	case 9:
+		return struct {
+			*httpLogRW
+			rwFlusher
+			rwReaderFrom
+		}{w, f, rf}
+	case 10:
+		return struct {
+			*httpLogRW
+			rwHijacker
+			rwReaderFrom
+		}{w, h, rf}
+	case 11:
+		return struct {
+			*httpLogRW
+			rwFlusher
+			rwHijacker
+			rwReaderFrom
+		}{w, f, h, rf}
+	case 12:
+		return struct {
+			*httpLogRW
+			rwPusher
+			rwReaderFrom
+		}{w, p, rf}
+	case 13:
+		return struct {
+			*httpLogRW
+			rwFlusher
+			rwPusher
+			rwReaderFrom
+		}{w, f, p, rf}
+	case 14:
+		return struct {
+			*httpLogRW
+			rwHijacker
+			rwPusher
+			rwReaderFrom
+		}{w, h, p, rf}
+	case 15:
+		return struct {
+			*httpLogRW
+			rwFlusher
+			rwHijacker
+			rwPusher
+			rwReaderFrom
+		}{w, f, h, p, rf}
+	}
+	return w
+}
@@ -0,0 +1,202 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// plainRW implements only http.ResponseWriter.
+type plainRW struct {
+	h    http.Header
+	code int
+	body bytes.Buffer
+}
+
+func (w *plainRW) Header() http.Header {
+	if w.h == nil {
+		w.h = http.Header{}
+	}
+	return w.h
+}
+func (w *plainRW) Write(b []byte) (int, error) { return w.body.Write(b) }
+func (w *plainRW) WriteHeader(code int)        { w.code = code }
+
+type flushRW struct {
+	plainRW
+	flushed int
+}
+
+func (w *flushRW) Flush() { w.flushed++ }
+
+type pushRW struct {
+	plainRW
+	pushed []string
+}
+
+func (w *pushRW) Push(target string, _ *http.PushOptions) error {
+	w.pushed = append(w.pushed, target)
+	return nil
+}
+
+type readFromRW struct {
+	plainRW
+	calls int
+}
+
+func (w *readFromRW) ReadFrom(r io.Reader) (int64, error) {
+	w.calls++
+	return io.Copy(&w.body, r)
+}
+
+type hijackRW struct {
+	plainRW
+	server net.Conn
+	err    error
+}
+
+func (w *hijackRW) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.err != nil {
+		return nil, nil, w.err
+	}
+	return w.server, bufio.NewReadWriter(bufio.NewReader(w.server), bufio.NewWriter(w.server)), nil
+}
+
+type allRW struct {
+	hijackRW
+}
+
+func (w *allRW) Flush()                               {}
+func (w *allRW) Push(string, *http.PushOptions) error { return nil }
+func (w *allRW) ReadFrom(r io.Reader) (int64, error)  { return io.Copy(&w.body, r) }
+
+func TestHTTPLogRW_ExposesOnlySupportedInterfaces(t *testing.T) {
+	cases := []struct {
+		name                      string
+		w                         http.ResponseWriter
+		flush, hijack, push, rfrm bool
+	}{
+		{"plain", &plainRW{}, false, false, false, false},
+		{"flusher", &flushRW{}, true, false, false, false},
+		{"hijacker", &hijackRW{}, false, true, false, false},
+		{"pusher", &pushRW{}, false, false, true, false},
+		{"readerfrom", &readFromRW{}, false, false, false, true},
+		{"all", &allRW{}, true, true, true, true},
+		{"recorder", httptest.NewRecorder(), true, false, false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ww := (&httpLogRW{ResponseWriter: c.w}).wrapped()
+			_, f := ww.(http.Flusher)
+			_, h := ww.(http.Hijacker)
+			_, p := ww.(http.Pusher)
+			_, rf := ww.(io.ReaderFrom)
+			assert.Equal(t, []bool{c.flush, c.hijack, c.push, c.rfrm}, []bool{f, h, p, rf})
+			u, ok := ww.(interface{ Unwrap() http.ResponseWriter })
+			assert.True(t, ok)
+			assert.Same(t, c.w, u.Unwrap())
+		})
+	}
+}
+
+func TestHTTPLogging_FlusherAndResponseController(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "data: 1\n\n")
+			w.(http.Flusher).Flush()
+			assert.NoError(t, http.NewResponseController(w).Flush())
+		}), &HTTPLogOptions{Mode: ColorOff})
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/events", nil))
+		assert.True(t, rr.Flushed)
+		assert.Contains(t, buf.String(), "status=200 bytes=9")
+	})
+}
+
+func TestHTTPLogging_ReaderFromCountsBytes(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		under := &readFromRW{}
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n, err := w.(io.ReaderFrom).ReadFrom(strings.NewReader("0123456789"))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(10), n)
+		}), &HTTPLogOptions{Mode: ColorOff})
+		h.ServeHTTP(under, httptest.NewRequest(http.MethodGet, "/file", nil))
+		assert.Equal(t, 1, under.calls)
+		assert.Contains(t, buf.String(), "status=200 bytes=10")
+	})
+}
+
+func TestHTTPLogging_PusherDelegates(t *testing.T) {
+	withStdReset(t, func() {
+		SetOutput(io.Discard)
+		under := &pushRW{}
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, w.(http.Pusher).Push("/app.js", nil))
+		}), nil)
+		h.ServeHTTP(under, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, []string{"/app.js"}, under.pushed)
+	})
+}
+
+func TestHTTPLogging_HijackCountsBytes(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		server, client := net.Pipe()
+		defer client.Close()
+		go func() { _, _ = io.Copy(io.Discard, client) }()
+		under := &hijackRW{server: server}
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, brw, err := w.(http.Hijacker).Hijack()
+			assert.NoError(t, err)
+			defer conn.Close()
+			_, _ = conn.Write([]byte("abc"))
+			_, _ = brw.WriteString("defg")
+			_ = brw.Flush()
+		}), &HTTPLogOptions{Mode: ColorOff})
+		h.ServeHTTP(under, httptest.NewRequest(http.MethodGet, "/ws", nil))
+		assert.Contains(t, buf.String(), "status=101 bytes=7")
+
+		// hijack errors are passed through untouched
+		buf.Reset()
+		failing := &hijackRW{err: errors.New("nope")}
+		h2 := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _, err := w.(http.Hijacker).Hijack()
+			assert.EqualError(t, err, "nope")
+		}), &HTTPLogOptions{Mode: ColorOff})
+		h2.ServeHTTP(failing, httptest.NewRequest(http.MethodGet, "/ws", nil))
+		assert.Contains(t, buf.String(), "status=200 bytes=0")
+	})
+}
+
+func TestHTTPLogRW_IgnoresInformationalStatus(t *testing.T) {
+	w := &httpLogRW{ResponseWriter: &plainRW{}}
+	w.WriteHeader(http.StatusEarlyHints)
+	w.WriteHeader(http.StatusNotFound)
+	assert.Equal(t, http.StatusNotFound, w.status)
+
+	w = &httpLogRW{ResponseWriter: &plainRW{}}
+	w.WriteHeader(http.StatusEarlyHints)
+	_, _ = w.Write([]byte("ok"))
+	assert.Equal(t, http.StatusOK, w.status)
+
+	w = &httpLogRW{ResponseWriter: &plainRW{}}
+	w.WriteHeader(http.StatusSwitchingProtocols)
+	assert.Equal(t, http.StatusSwitchingProtocols, w.status)
+}
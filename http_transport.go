@@ -0,0 +1,268 @@
+package log
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportOptions customizes LoggingTransport.
+type TransportOptions struct {
+	// Logger receives the log lines (default: the package default logger).
+	Logger *Logger
+	// Mode is the color mode for method/URL highlighting, as in HTTPLogOptions.
+	Mode ColorMode
+	// Levels sets the request line level and the response levels by status
+	// (default DefaultHTTPLevels()). Transport errors use Levels.ServerError.
+	Levels *HTTPLevels
+	// SkipRequestLine disables the line logged before the request is sent.
+	SkipRequestLine bool
+	// Redactor scrubs the URL query and body previews (default NewRedactor()).
+	Redactor *Redactor
+	// LogRequestBody adds a preview of the request body to the request line.
+	LogRequestBody bool
+	// LogResponseBody adds a preview of the response body to the response line.
+	LogResponseBody bool
+	// MaxBodyBytes caps body previews (default 64KB when zero or negative).
+	MaxBodyBytes int
+	// BodyContentTypes restricts body previews to these media types
+	// (default DefaultBodyContentTypes).
+	BodyContentTypes []string
+	// RequestIDHeader is set on outgoing requests from the request id stored
+	// in the request context, unless already present (default "X-Request-ID").
+	RequestIDHeader string
+	// Retries is the number of additional attempts for requests that fail with
+	// a transport error or a 502/503/504 response. Only idempotent requests are
+	// retried: GET, HEAD, OPTIONS and TRACE, or any method carrying an
+	// Idempotency-Key or X-Idempotency-Key header, and a body needs GetBody.
+	Retries int
+	// RetryBackoff is the delay before each retry, multiplied by the attempt
+	// number (default 100ms).
+	RetryBackoff time.Duration
+}
+
+// LoggingTransport is an http.RoundTripper that logs outbound requests with
+// the same color and level conventions as HTTPLogging. The response line is
+// logged once the response body has been read to EOF or closed, so that it
+// reports the bytes received and the full duration; a 101 Switching Protocols
+// response is logged at once and its body is left unwrapped.
+type LoggingTransport struct {
+	base    http.RoundTripper
+	o       TransportOptions
+	lg      *Logger
+	levels  HTTPLevels
+	colorOn bool
+}
+
+// NewLoggingTransport wraps base (http.DefaultTransport when nil).
+func NewLoggingTransport(base http.RoundTripper, opts *TransportOptions) *LoggingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	var o TransportOptions
+	if opts != nil {
+		o = *opts
+	}
+	t := &LoggingTransport{base: base, lg: o.Logger, levels: DefaultHTTPLevels()}
+	if t.lg == nil {
+		t.lg = std
+	}
+	if o.Levels != nil {
+		t.levels = *o.Levels
+	}
+	if o.Redactor == nil {
+		o.Redactor = NewRedactor()
+	}
+	if o.MaxBodyBytes <= 0 {
+		o.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if o.RequestIDHeader == "" {
+		o.RequestIDHeader = "X-Request-ID"
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = 100 * time.Millisecond
+	}
+	t.o = o
+	t.colorOn = (&HTTPLogOptions{Mode: o.Mode}).enabled()
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	// RoundTrippers must not modify the caller's request; clone before changes.
+	cloned := false
+	reqID := RequestIDFromContext(req.Context())
+	if reqID != "" && req.Header.Get(t.o.RequestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		cloned = true
+		req.Header.Set(t.o.RequestIDHeader, reqID)
+	}
+
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = t.o.Redactor.RedactQuery(u.RawQuery)
+	msg := colorWrap(req.Method, methodColor(req.Method), t.colorOn) + " " + colorWrap(u.String(), ansiBold, t.colorOn)
+
+	var bodyPreview string
+	if t.o.LogRequestBody && req.Body != nil && req.Body != http.NoBody &&
+		textualContentType(req.Header.Get("Content-Type"), t.o.BodyContentTypes) {
+		data, truncated, replay := peekBody(req.Body, t.o.MaxBodyBytes)
+		if !cloned {
+			req = req.Clone(req.Context())
+		}
+		req.Body = replay
+		if previewable(req.Header.Get("Content-Type"), data, t.o.BodyContentTypes) {
+			bodyPreview = t.o.Redactor.RedactBody(string(data))
+			if truncated {
+				bodyPreview += "…(truncated)"
+			}
+		}
+	}
+	if !t.o.SkipRequestLine {
+		attrs := []any{}
+		if reqID != "" {
+			attrs = append(attrs, "request_id", reqID)
+		}
+		if bodyPreview != "" {
+			attrs = append(attrs, "body", bodyPreview)
+		}
+		t.lg.Log(t.levels.Request, msg, attrs...)
+	}
+
+	resp, retries, err := t.send(req)
+	if err != nil {
+		attrs := []any{"error", err.Error(), "duration", time.Since(start).String()}
+		if retries > 0 {
+			attrs = append(attrs, "retries", retries)
+		}
+		if reqID != "" {
+			attrs = append(attrs, "request_id", reqID)
+		}
+		t.lg.Log(t.levels.ServerError, msg, attrs...)
+		return nil, err
+	}
+
+	lb := &loggedBody{ReadCloser: resp.Body, header: resp.Header}
+	if t.o.LogResponseBody && textualContentType(resp.Header.Get("Content-Type"), t.o.BodyContentTypes) {
+		lb.capture = &bodyCapture{limit: t.o.MaxBodyBytes, types: t.o.BodyContentTypes}
+	}
+	status := resp.StatusCode
+	lb.done = func() {
+		attrs := []any{"status", status, "bytes", lb.n, "duration", time.Since(start).String()}
+		if retries > 0 {
+			attrs = append(attrs, "retries", retries)
+		}
+		if reqID != "" {
+			attrs = append(attrs, "request_id", reqID)
+		}
+		if pb := lb.capture.preview(); pb != "" {
+			attrs = append(attrs, "resp_body", t.o.Redactor.RedactBody(pb))
+		}
+		t.lg.Log(t.levels.forStatus(status), msg, attrs...)
+	}
+	if status == http.StatusSwitchingProtocols {
+		// The body is the upgraded connection, which callers type-assert to
+		// io.ReadWriteCloser; leave it unwrapped and log the response now.
+		lb.done()
+		return resp, nil
+	}
+	resp.Body = lb
+	return resp, nil
+}
+
+// send performs the request, retrying per TransportOptions.
+func (t *LoggingTransport) send(req *http.Request) (*http.Response, int, error) {
+	retries := 0
+	for {
+		resp, err := t.base.RoundTrip(req)
+		if retries >= t.o.Retries || !idempotent(req) || !retryable(resp, err) {
+			return resp, retries, err
+		}
+		next, ok := rewind(req)
+		if !ok {
+			return resp, retries, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			_ = resp.Body.Close()
+		}
+		retries++
+		timer := time.NewTimer(time.Duration(retries) * t.o.RetryBackoff)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, retries, req.Context().Err()
+		case <-timer.C:
+		}
+		req = next
+	}
+}
+
+// idempotent reports whether req may be sent again without side effects,
+// using the same rule as net/http's own retries.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewind returns a copy of req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, true
+}
+
+// loggedBody counts and optionally previews a response body, calling done once
+// on EOF, read error or Close.
+type loggedBody struct {
+	io.ReadCloser
+	n       int64
+	header  http.Header
+	capture *bodyCapture
+	done    func()
+	once    sync.Once
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.capture != nil && n > 0 {
+		b.capture.write(0, b.header, p[:n]) // every status is previewed; the type is decided on the first read
+	}
+	if err != nil {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
@@ -1,6 +1,10 @@
 package log
 
-import "strconv"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // Level represents the severity of a log record.
 //
@@ -64,3 +68,42 @@ func (l Level) String() string {
 		return "LEVEL(" + strconv.Itoa(int(l)) + ")"
 	}
 }
+
+// ParseLevel parses a level name such as "debug", "WARN" or "warning"
+// (case-insensitive, surrounding spaces ignored). Numeric values are accepted too.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "all":
+		return LevelAll, nil
+	case "off", "none":
+		return LevelOff, nil
+	case "trace":
+		return LevelTrace, nil
+	case "verbose":
+		return LevelVerbose, nil
+	case "debug":
+		return LevelDebug, nil
+	case "detail":
+		return LevelDetail, nil
+	case "info":
+		return LevelInfo, nil
+	case "notice":
+		return LevelNotice, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "critical", "crit":
+		return LevelCritical, nil
+	case "alert":
+		return LevelAlert, nil
+	case "fatal":
+		return LevelFatal, nil
+	case "panic":
+		return LevelPanic, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		return Level(n), nil
+	}
+	return 0, fmt.Errorf("log: unknown level %q", s)
+}
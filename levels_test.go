@@ -24,3 +24,19 @@ func TestLevelString(t *testing.T) {
 	// Unknown
 	assert.Equal(t, "LEVEL(123)", Level(123).String())
 }
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{
+		"all": LevelAll, "OFF": LevelOff, "trace": LevelTrace, "Verbose": LevelVerbose,
+		"debug": LevelDebug, "detail": LevelDetail, " info ": LevelInfo, "notice": LevelNotice,
+		"warn": LevelWarn, "warning": LevelWarn, "ERROR": LevelError, "crit": LevelCritical,
+		"alert": LevelAlert, "fatal": LevelFatal, "panic": LevelPanic, "6": Level(6),
+	}
+	for in, want := range cases {
+		got, err := ParseLevel(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseLevel("loud")
+	assert.EqualError(t, err, `log: unknown level "loud"`)
+}
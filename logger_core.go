@@ -12,16 +12,28 @@ import (
 // File registry to auto-close files opened by the package
 var (
 	openFilesMu sync.Mutex
-	openFiles   []*os.File
+	openFiles   []io.Closer
 )
 
 // registerFile adds a file to the auto-close registry
-func registerFile(f *os.File) {
+func registerFile(f io.Closer) {
 	openFilesMu.Lock()
 	openFiles = append(openFiles, f)
 	openFilesMu.Unlock()
 }
 
+// unregisterFile removes a file from the auto-close registry
+func unregisterFile(f io.Closer) {
+	openFilesMu.Lock()
+	defer openFilesMu.Unlock()
+	for i, g := range openFiles {
+		if g == f {
+			openFiles = append(openFiles[:i], openFiles[i+1:]...)
+			return
+		}
+	}
+}
+
 // Close closes all files opened by the package helpers.
 // Call this before your application exits to ensure clean shutdown.
 func Close() {
@@ -47,24 +59,55 @@ type Logger struct {
 	prefix  string
 	flags   int
 	outputs []output
+	attrs   []Attr // attached by With
 	now     func() time.Time
+	exit    func(int)    // nil: package exit function
+	panicFn func(string) // nil: panic
+
+	configured *builtOutputs // set by Configure; owns its open files
+
+	// For loggers made by With from a configured logger: the logger owning
+	// the configuration and the generation outputs were copied from. Once
+	// root is reconfigured, records go to its current outputs instead.
+	root    *Logger
+	derived *builtOutputs
 }
 
-// globalNow allows tests to control time used by new loggers.
-var globalNow = time.Now
+// Package-level hooks set by SetNowFunc and SetExitFunc.
+var (
+	hooksMu   sync.RWMutex
+	globalNow = time.Now
+	exitFunc  = os.Exit
+)
+
+func currentNow() func() time.Time {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return globalNow
+}
+
+func currentExit() func(int) {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return exitFunc
+}
 
 // New creates a new Logger that writes to w for all levels by default,
-// keeping stdlib's constructor shape for drop-in adoption.
-func New(w io.Writer, prefix string, flag int) *Logger {
+// keeping stdlib's constructor shape for drop-in adoption. Options such as
+// WithClock and WithExitFunc scope test hooks to this Logger.
+func New(w io.Writer, prefix string, flag int, opts ...Option) *Logger {
 	l := &Logger{
 		prefix: prefix,
 		flags:  flag,
-		now:    globalNow,
+		now:    currentNow(),
 	}
 	if w == nil {
 		w = os.Stderr
 	}
 	l.outputs = []output{{h: &WriterHandler{w: w}, min: LevelDebug}} // default min: debug
+	for _, opt := range opts {
+		opt(l)
+	}
 	return l
 }
 
@@ -128,18 +171,51 @@ func (l *Logger) AddHandler(minLevel Level, h Handler) {
 }
 
 // With returns a shallow copy of the Logger with additional attributes applied
-// to every record. Placeholder: attributes are included in records but only
-// minimally formatted by the default WriterHandler.
+// to every record. The copy shares handlers with l but later changes to either
+// logger's outputs, prefix or flags do not affect the other, except that when
+// l's outputs came from Configure the copy follows later reconfigurations.
 func (l *Logger) With(kv ...any) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	nl := &Logger{
 		prefix:  l.prefix,
 		flags:   l.flags,
-		outputs: l.outputs,
+		outputs: append([]output(nil), l.outputs...),
+		attrs:   append(append([]Attr(nil), l.attrs...), toAttrs(kv)...),
 		now:     l.now,
+		exit:    l.exit,
+		panicFn: l.panicFn,
+		root:    l.root,
+		derived: l.derived,
+	}
+	if l.configured != nil {
+		nl.root, nl.derived = l, l.configured
 	}
 	return nl
 }
 
+// exitProcess calls the Logger's exit function, or the package one.
+func (l *Logger) exitProcess(code int) {
+	l.mu.Lock()
+	f := l.exit
+	l.mu.Unlock()
+	if f == nil {
+		f = currentExit()
+	}
+	f(code)
+}
+
+// panicWith calls the Logger's panic function, or panics with msg.
+func (l *Logger) panicWith(msg string) {
+	l.mu.Lock()
+	f := l.panicFn
+	l.mu.Unlock()
+	if f == nil {
+		panic(msg)
+	}
+	f(msg)
+}
+
 // Internal helpers used by API and methods
 func (l *Logger) logStructured(level Level, msg string, kv ...any) {
 	attrs := toAttrs(kv)
@@ -156,7 +232,27 @@ func (l *Logger) dispatch(level Level, msg string, attrs []Attr) {
 	flags := l.flags
 	now := l.now
 	outs := append([]output(nil), l.outputs...)
+	if len(l.attrs) > 0 {
+		attrs = append(append(make([]Attr, 0, len(l.attrs)+len(attrs)), l.attrs...), attrs...)
+	}
+	// Hold the configured outputs open until this record is handled.
+	gen, root := l.configured, l.root
+	if gen != nil {
+		gen.inflight.Add(1)
+		defer gen.inflight.Done()
+	}
 	l.mu.Unlock()
+	if gen == nil && l.derived != nil {
+		root.mu.Lock()
+		if cur := root.configured; cur != nil {
+			if cur != l.derived {
+				outs = append([]output(nil), root.outputs...)
+			}
+			cur.inflight.Add(1)
+			defer cur.inflight.Done()
+		}
+		root.mu.Unlock()
+	}
 
 	r := Record{
 		Time:    now(),
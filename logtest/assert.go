@@ -0,0 +1,131 @@
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chrisjoyce911/log"
+)
+
+// AssertLogged fails t unless a record at level has a message containing msg.
+func AssertLogged(t testing.TB, h *CaptureHandler, level log.Level, msg string) bool {
+	t.Helper()
+	for _, r := range h.Records(level) {
+		if strings.Contains(r.Message, msg) {
+			return true
+		}
+	}
+	t.Errorf("no %s record with message containing %q; captured:\n%s", strings.TrimSpace(level.String()), msg, dump(h))
+	return false
+}
+
+// AssertNotLogged fails t if a record at or above level has a message
+// containing msg.
+func AssertNotLogged(t testing.TB, h *CaptureHandler, level log.Level, msg string) bool {
+	t.Helper()
+	for _, r := range h.All() {
+		if r.Level >= level && strings.Contains(r.Message, msg) {
+			t.Errorf("unexpected record: %s", Format(r))
+			return false
+		}
+	}
+	return true
+}
+
+// AssertAttr fails t unless some record has attr key equal to value.
+func AssertAttr(t testing.TB, h *CaptureHandler, key string, value any) bool {
+	t.Helper()
+	if h.HasAttr(key, value) {
+		return true
+	}
+	var seen []string
+	for _, r := range h.All() {
+		if v, ok := Attr(r, key); ok {
+			seen = append(seen, fmt.Sprintf("  %#v in: %s", v, Format(r)))
+		}
+	}
+	if len(seen) == 0 {
+		t.Errorf("no record has attr %q; captured:\n%s", key, dump(h))
+	} else {
+		t.Errorf("no record has %s=%#v; found:\n%s", key, value, strings.Join(seen, "\n"))
+	}
+	return false
+}
+
+// AssertMessages fails t unless the captured messages are exactly want, in
+// order, reporting a line diff otherwise.
+func AssertMessages(t testing.TB, h *CaptureHandler, want ...string) bool {
+	t.Helper()
+	return assertLines(t, "messages", want, h.Messages())
+}
+
+// AssertLines fails t unless the captured records, rendered with Format, are
+// exactly want, reporting a line diff otherwise.
+func AssertLines(t testing.TB, h *CaptureHandler, want ...string) bool {
+	t.Helper()
+	recs := h.All()
+	got := make([]string, len(recs))
+	for i, r := range recs {
+		got[i] = Format(r)
+	}
+	return assertLines(t, "records", want, got)
+}
+
+func assertLines(t testing.TB, what string, want, got []string) bool {
+	t.Helper()
+	if len(want) == 0 && len(got) == 0 || reflect.DeepEqual(want, got) {
+		return true
+	}
+	t.Errorf("%s differ (-want +got):\n%s", what, Diff(want, got))
+	return false
+}
+
+func dump(h *CaptureHandler) string {
+	recs := h.All()
+	if len(recs) == 0 {
+		return "  (none)"
+	}
+	lines := make([]string, len(recs))
+	for i, r := range recs {
+		lines[i] = "  " + Format(r)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Diff returns a line diff of want and got: unchanged lines are indented,
+// removed lines start with "-" and added lines with "+".
+func Diff(want, got []string) string {
+	// longest common subsequence table
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	b := &strings.Builder{}
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			b.WriteString("  " + want[i] + "\n")
+			i++
+			j++
+		case i < len(want) && (j == len(got) || lcs[i+1][j] >= lcs[i][j+1]):
+			b.WriteString("- " + want[i] + "\n")
+			i++
+		default:
+			b.WriteString("+ " + got[j] + "\n")
+			j++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+// Package logtest provides helpers for testing code that logs with
+// github.com/chrisjoyce911/log: an in-memory CaptureHandler with query helpers,
+// assertions with readable failure output, and a Logger that writes through
+// testing.TB so log lines only appear for failing tests.
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/chrisjoyce911/log"
+)
+
+// CaptureHandler records every Record it handles. It is safe for concurrent use.
+type CaptureHandler struct {
+	mu      sync.Mutex
+	records []log.Record
+}
+
+// NewCaptureHandler returns an empty CaptureHandler.
+func NewCaptureHandler() *CaptureHandler { return &CaptureHandler{} }
+
+// NewCapture returns a Logger that sends records of every level to a new
+// CaptureHandler, and the handler.
+func NewCapture() (*log.Logger, *CaptureHandler) {
+	h := NewCaptureHandler()
+	l := log.New(discard{}, "", 0)
+	l.AddHandler(log.LevelAll, h)
+	return l, h
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+
+func (h *CaptureHandler) Handle(r log.Record) error {
+	r.Attrs = append([]log.Attr(nil), r.Attrs...)
+	h.mu.Lock()
+	h.records = append(h.records, r)
+	h.mu.Unlock()
+	return nil
+}
+
+// All returns a copy of the captured records in order.
+func (h *CaptureHandler) All() []log.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]log.Record(nil), h.records...)
+}
+
+// Len returns the number of captured records.
+func (h *CaptureHandler) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.records)
+}
+
+// Reset discards the captured records.
+func (h *CaptureHandler) Reset() {
+	h.mu.Lock()
+	h.records = nil
+	h.mu.Unlock()
+}
+
+// Records returns the captured records logged at exactly level.
+func (h *CaptureHandler) Records(level log.Level) []log.Record {
+	var out []log.Record
+	for _, r := range h.All() {
+		if r.Level == level {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Messages returns the messages of all captured records.
+func (h *CaptureHandler) Messages() []string {
+	recs := h.All()
+	out := make([]string, len(recs))
+	for i, r := range recs {
+		out[i] = r.Message
+	}
+	return out
+}
+
+// FindMessage returns the first record whose message contains substr.
+func (h *CaptureHandler) FindMessage(substr string) (log.Record, bool) {
+	for _, r := range h.All() {
+		if strings.Contains(r.Message, substr) {
+			return r, true
+		}
+	}
+	return log.Record{}, false
+}
+
+// HasAttr reports whether any record has an attr key whose value equals value
+// (compared with reflect.DeepEqual).
+func (h *CaptureHandler) HasAttr(key string, value any) bool {
+	for _, r := range h.All() {
+		if v, ok := Attr(r, key); ok && reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Attr returns the value of the last attr named key in r.
+func Attr(r log.Record, key string) (any, bool) {
+	for i := len(r.Attrs) - 1; i >= 0; i-- {
+		if r.Attrs[i].Key == key {
+			return r.Attrs[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Format renders r as a single line, "LEVEL message key=value ...", for
+// failure messages and comparisons.
+func Format(r log.Record) string {
+	b := &strings.Builder{}
+	b.WriteString(strings.TrimSpace(r.Level.String()))
+	if r.Prefix != "" {
+		b.WriteString(" [" + r.Prefix + "]")
+	}
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	for _, a := range r.Attrs {
+		fmt.Fprintf(b, " %s=%v", a.Key, a.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package logtest
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chrisjoyce911/log"
+)
+
+// Update makes Golden rewrite golden files instead of comparing against them.
+// Bind it to a flag in your test package to opt in:
+//
+//	func init() { flag.BoolVar(&logtest.Update, "update", false, "rewrite golden files") }
+//
+// A boolean -update flag defined by the test binary is honored as well.
+var Update bool
+
+func updating() bool {
+	if Update {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// Golden routes the default logger to a buffer for the rest of the test and,
+// when the test ends, compares the output normalized by log.Normalize with
+// testdata/<name>.golden. Set Update to write the file. Anything else written
+// to the returned writer, such as a CLI's stdout, is compared verbatim. Tests
+// using Golden must not run in parallel.
+func Golden(t testing.TB, name string) io.Writer {
+	t.Helper()
+	buf := &lockedBuffer{}
+	restore := log.SetGoldenMode(buf)
+	t.Cleanup(func() {
+		restore()
+		checkGolden(t, filepath.Join("testdata", name+".golden"), buf.String(), updating())
+	})
+	return buf
+}
+
+// checkGolden compares got with the file at path, or rewrites the file.
+func checkGolden(t testing.TB, path, got string, rewrite bool) {
+	t.Helper()
+	if rewrite {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("reading golden file: %v (set logtest.Update or -update to create it)", err)
+		return
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s (-want +got):\n%s", path,
+			Diff(strings.Split(string(want), "\n"), strings.Split(got, "\n")))
+	}
+}
+
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.String()
+}
@@ -0,0 +1,37 @@
+package logtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chrisjoyce911/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGolden(t *testing.T) {
+	out := Golden(t, "golden_example")
+	log.Info("request served", "request_id", log.NewRequestID(), "duration", 1234*time.Microsecond, "pid", os.Getpid())
+	log.Warn("retrying", "after", 2*time.Second)
+	fmt.Fprintln(out, "exit status 0")
+}
+
+func TestCheckGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "x.golden")
+	ft := &fakeTB{}
+	checkGolden(ft, path, "a\n", false)
+	assert.Len(t, ft.errors, 1)
+	assert.Contains(t, ft.errors[0], "set logtest.Update")
+
+	ft = &fakeTB{}
+	checkGolden(ft, path, "a\nb\n", true)
+	checkGolden(ft, path, "a\nb\n", false)
+	assert.Empty(t, ft.errors)
+
+	checkGolden(ft, path, "a\nc\n", false)
+	assert.Len(t, ft.errors, 1)
+	assert.Contains(t, ft.errors[0], "- b\n")
+	assert.Contains(t, ft.errors[0], "+ c\n")
+}
@@ -0,0 +1,74 @@
+package logtest
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chrisjoyce911/log"
+)
+
+// New returns a Logger that writes each line with t.Log, so output is shown
+// only when the test fails (or with -v) and belongs to that test. t.Log
+// attributes every line to this package, so each line is prefixed with the
+// file:line of the code that logged it. Lines logged after the test has
+// finished are dropped instead of panicking.
+func New(t testing.TB) *log.Logger {
+	w := &tbWriter{t: t}
+	t.Cleanup(func() {
+		w.mu.Lock()
+		w.done = true
+		w.mu.Unlock()
+	})
+	return log.New(w, "", 0)
+}
+
+type tbWriter struct {
+	mu   sync.Mutex
+	t    testing.TB
+	done bool
+}
+
+func (w *tbWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.done {
+		w.t.Log(callerPrefix() + strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+// callerPrefix returns "file.go:line: " for the code that logged, skipping
+// frames in this module and the standard library, or "" if there is none.
+func callerPrefix() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		f, more := frames.Next()
+		if isCallerFrame(f) {
+			return filepath.Base(f.File) + ":" + strconv.Itoa(f.Line) + ": "
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func isCallerFrame(f runtime.Frame) bool {
+	if strings.HasSuffix(f.File, "_test.go") {
+		return true
+	}
+	fn := f.Function
+	if strings.HasPrefix(fn, "github.com/chrisjoyce911/log.") || strings.HasPrefix(fn, "github.com/chrisjoyce911/log/logtest.") {
+		return false
+	}
+	if strings.HasPrefix(fn, "main.") {
+		return true
+	}
+	// standard library import paths have no dot in their first element
+	first, _, _ := strings.Cut(fn, "/")
+	return strings.Contains(first, ".") && strings.Contains(fn, "/")
+}
@@ -0,0 +1,108 @@
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chrisjoyce911/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTB records failures and log lines instead of reporting them.
+type fakeTB struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+	logs   []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.mu.Lock()
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+	f.mu.Unlock()
+}
+
+func (f *fakeTB) Log(args ...any) {
+	f.mu.Lock()
+	f.logs = append(f.logs, fmt.Sprint(args...))
+	f.mu.Unlock()
+}
+
+func TestCaptureQueries(t *testing.T) {
+	l, h := NewCapture()
+	l.Trace("connecting", "host", "db1")
+	l.Info("connected", "host", "db1", "ms", 12)
+	l.Warn("slow query", "ms", 250)
+
+	assert.Equal(t, 3, h.Len())
+	assert.Len(t, h.Records(log.LevelInfo), 1)
+	assert.Len(t, h.Records(log.LevelError), 0)
+	r, ok := h.FindMessage("slow")
+	assert.True(t, ok)
+	assert.Equal(t, log.LevelWarn, r.Level)
+	_, ok = h.FindMessage("nope")
+	assert.False(t, ok)
+	assert.True(t, h.HasAttr("ms", 250))
+	assert.False(t, h.HasAttr("ms", "250"))
+	assert.Equal(t, []string{"connecting", "connected", "slow query"}, h.Messages())
+	assert.Equal(t, "INFO connected host=db1 ms=12", Format(h.All()[1]))
+
+	h.Reset()
+	assert.Equal(t, 0, h.Len())
+}
+
+func TestAssertionsPass(t *testing.T) {
+	l, h := NewCapture()
+	l.Error("write failed", "path", "/tmp/x")
+	AssertLogged(t, h, log.LevelError, "write")
+	AssertNotLogged(t, h, log.LevelWarn, "retry")
+	AssertAttr(t, h, "path", "/tmp/x")
+	AssertMessages(t, h, "write failed")
+	AssertLines(t, h, "ERROR write failed path=/tmp/x")
+}
+
+func TestAssertionsReport(t *testing.T) {
+	l, h := NewCapture()
+	l.Info("a")
+	l.Info("b", "user", "ann")
+	l.Info("c")
+
+	ft := &fakeTB{}
+	assert.False(t, AssertLogged(ft, h, log.LevelError, "b"))
+	assert.False(t, AssertNotLogged(ft, h, log.LevelInfo, "c"))
+	assert.False(t, AssertAttr(ft, h, "user", "bob"))
+	assert.False(t, AssertAttr(ft, h, "id", 1))
+	assert.False(t, AssertMessages(ft, h, "a", "x", "c", "d"))
+
+	assert.Equal(t, []string{
+		"no ERROR record with message containing \"b\"; captured:\n  INFO a\n  INFO b user=ann\n  INFO c",
+		"unexpected record: INFO c",
+		"no record has user=\"bob\"; found:\n  \"ann\" in: INFO b user=ann",
+		"no record has attr \"id\"; captured:\n  INFO a\n  INFO b user=ann\n  INFO c",
+		"messages differ (-want +got):\n  a\n- x\n+ b\n  c\n- d\n",
+	}, ft.errors)
+}
+
+func TestNewRoutesToTestLog(t *testing.T) {
+	ft := &fakeTB{TB: t}
+	l := New(ft)
+	l.Info("hello", "k", "v")
+	l.Debugf("n=%d", 2)
+	assert.Equal(t, []string{"logtest_test.go:93: INFO     hello k=v", "logtest_test.go:94: DEBUG    n=2"}, ft.logs)
+}
+
+func TestNewDropsAfterCleanup(t *testing.T) {
+	var l *log.Logger
+	var inner *fakeTB
+	t.Run("sub", func(t *testing.T) {
+		inner = &fakeTB{TB: t}
+		l = New(inner)
+	})
+	l.Info("late")
+	assert.Empty(t, inner.logs)
+	assert.False(t, strings.Contains(strings.Join(inner.logs, ""), "late"))
+}
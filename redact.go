@@ -0,0 +1,305 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RedactedText is printed in place of secrets and masked values.
+const RedactedText = "[REDACTED]"
+
+// Secret wraps a sensitive string so that it always prints as [REDACTED],
+// whichever handler formats it.
+type Secret string
+
+func (Secret) String() string   { return RedactedText }
+func (Secret) GoString() string { return RedactedText }
+
+// MarshalJSON keeps the secret out of JSON output.
+func (Secret) MarshalJSON() ([]byte, error) { return json.Marshal(RedactedText) }
+
+// Common value patterns for RedactRule.Value. Matches of PatternCreditCard are
+// only redacted when they pass the Luhn checksum.
+var (
+	PatternCreditCard = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	PatternEmail      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	PatternBearer     = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	PatternJWT        = regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+)
+
+// RedactMode selects how a matched value is replaced.
+type RedactMode int
+
+const (
+	RedactMask RedactMode = iota // replace with [REDACTED]
+	RedactHash                   // replace with a short SHA-256 digest so values stay correlatable
+	RedactDrop                   // remove the attr (key rules) or the matched text (value rules)
+)
+
+// RedactRule matches attrs by key or text by value pattern.
+// Key is a case-insensitive glob (path.Match syntax, e.g. "*password*");
+// KeyPattern is a regular expression tested against the key; Value is a
+// pattern replaced wherever it occurs in messages and string values.
+type RedactRule struct {
+	Key        string
+	KeyPattern *regexp.Regexp
+	Value      *regexp.Regexp
+	Mode       RedactMode
+}
+
+// DefaultRedactRules masks common credential keys and value patterns.
+func DefaultRedactRules() []RedactRule {
+	return []RedactRule{
+		{Key: "*password*"},
+		{Key: "*passwd*"},
+		{Key: "*secret*"},
+		{Key: "*token*"},
+		{Key: "*api?key*"},
+		{Key: "authorization"},
+		{Key: "cookie"},
+		{Key: "set-cookie"},
+		{Value: PatternBearer},
+		{Value: PatternJWT},
+		{Value: PatternCreditCard},
+		{Value: PatternEmail},
+	}
+}
+
+// Redactor applies RedactRules to records, attrs and free text.
+type Redactor struct {
+	Rules []RedactRule
+}
+
+// NewRedactor returns a Redactor with the given rules, or DefaultRedactRules when none are given.
+func NewRedactor(rules ...RedactRule) *Redactor {
+	if len(rules) == 0 {
+		rules = DefaultRedactRules()
+	}
+	return &Redactor{Rules: rules}
+}
+
+// RedactRecord returns a copy of rec with its message and attrs redacted.
+func (x *Redactor) RedactRecord(rec Record) Record {
+	rec.Message = x.RedactString(rec.Message)
+	rec.Attrs = x.RedactAttrs(rec.Attrs)
+	return rec
+}
+
+// RedactAttrs returns a redacted copy of attrs. The input slice is not modified.
+func (x *Redactor) RedactAttrs(attrs []Attr) []Attr {
+	if len(attrs) == 0 {
+		return attrs
+	}
+	out := make([]Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if rule, ok := x.keyRule(a.Key); ok {
+			if rule.Mode == RedactDrop {
+				continue
+			}
+			out = append(out, Attr{Key: a.Key, Value: replaceWith(rule.Mode, fmt.Sprint(a.Value))})
+			continue
+		}
+		out = append(out, Attr{Key: a.Key, Value: x.redactValue(a.Value)})
+	}
+	return out
+}
+
+// RedactString applies the value rules to s.
+func (x *Redactor) RedactString(s string) string {
+	for _, rule := range x.Rules {
+		if rule.Value == nil {
+			continue
+		}
+		s = rule.Value.ReplaceAllStringFunc(s, func(m string) string {
+			if rule.Value == PatternCreditCard && !luhnValid(m) {
+				return m
+			}
+			if rule.Mode == RedactDrop {
+				return ""
+			}
+			return replaceWith(rule.Mode, m)
+		})
+	}
+	return s
+}
+
+// RedactBody redacts a request or response body preview. Keys of JSON objects,
+// at any depth and inside arrays, and of form-encoded bodies (a=1&b=2) are
+// checked against key rules; all text is then passed through RedactString.
+// Bodies that do not parse, such as truncated previews, have "key": value
+// pairs matched textually so a cut-off value is still redacted.
+func (x *Redactor) RedactBody(s string) string {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		switch v.(type) {
+		case map[string]any, []any:
+			if b, err := json.Marshal(x.redactJSON(v)); err == nil {
+				s = string(b)
+			}
+		}
+	} else if formBodyPattern.MatchString(s) {
+		return x.RedactQuery(s)
+	} else {
+		s = x.redactJSONText(s)
+	}
+	return x.RedactString(s)
+}
+
+// formBodyPattern matches text shaped like an application/x-www-form-urlencoded
+// body: key=value pairs joined by & with no whitespace.
+var formBodyPattern = regexp.MustCompile(`^[^\s=&{}\[\]"]+=[^\s]*$`)
+
+// jsonPairPattern matches "key": value, where a string value may be
+// unterminated at the end of the text. Groups: key, value, trailing comma.
+var jsonPairPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*("(?:[^"\\]|\\.)*(?:"|\\?$)|[^,}\]\s]+)(\s*,\s*)?`)
+
+// redactJSONText applies the key rules to JSON-like text that did not parse.
+func (x *Redactor) redactJSONText(s string) string {
+	return jsonPairPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := jsonPairPattern.FindStringSubmatch(m)
+		rule, ok := x.keyRule(sub[1])
+		if !ok {
+			return m
+		}
+		if rule.Mode == RedactDrop {
+			return ""
+		}
+		val := strings.TrimSuffix(strings.TrimPrefix(sub[2], `"`), `"`)
+		return m[:len(m)-len(sub[2])-len(sub[3])] + `"` + replaceWith(rule.Mode, val) + `"` + sub[3]
+	})
+}
+
+// RedactQuery redacts the values of query parameters whose names match a key
+// rule, then applies the value rules. Parameter order is preserved.
+func (x *Redactor) RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	parts := strings.Split(rawQuery, "&")
+	out := parts[:0]
+	for _, p := range parts {
+		k, v, hasValue := strings.Cut(p, "=")
+		if rule, ok := x.keyRule(k); ok && hasValue {
+			if rule.Mode == RedactDrop {
+				continue
+			}
+			p = k + "=" + replaceWith(rule.Mode, v)
+		}
+		out = append(out, p)
+	}
+	return x.RedactString(strings.Join(out, "&"))
+}
+
+// redactJSON returns a redacted copy of decoded JSON; maps and slices are
+// copied at every level so the caller's data is never modified.
+func (x *Redactor) redactJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, val := range t {
+			if rule, ok := x.keyRule(k); ok {
+				if rule.Mode != RedactDrop {
+					m[k] = replaceWith(rule.Mode, fmt.Sprint(val))
+				}
+				continue
+			}
+			m[k] = x.redactJSON(val)
+		}
+		return m
+	case []any:
+		out := make([]any, len(t))
+		for i := range t {
+			out[i] = x.redactJSON(t[i])
+		}
+		return out
+	}
+	return v
+}
+
+func (x *Redactor) redactValue(v any) any {
+	switch t := v.(type) {
+	case Secret:
+		return t
+	case string:
+		return x.RedactString(t)
+	case []byte:
+		return x.RedactString(string(t))
+	case error:
+		if s := t.Error(); x.RedactString(s) != s {
+			return x.RedactString(s)
+		}
+	case fmt.Stringer:
+		if s := t.String(); x.RedactString(s) != s {
+			return x.RedactString(s)
+		}
+	case map[string]any, []any:
+		return x.redactJSON(t)
+	}
+	return v
+}
+
+func (x *Redactor) keyRule(key string) (RedactRule, bool) {
+	lk := strings.ToLower(key)
+	for _, rule := range x.Rules {
+		if rule.Key != "" {
+			if ok, _ := path.Match(strings.ToLower(rule.Key), lk); ok {
+				return rule, true
+			}
+		}
+		if rule.KeyPattern != nil && rule.KeyPattern.MatchString(key) {
+			return rule, true
+		}
+	}
+	return RedactRule{}, false
+}
+
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+func replaceWith(mode RedactMode, s string) string {
+	if mode == RedactHash {
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	}
+	return RedactedText
+}
+
+// RedactingHandler redacts messages and attrs before passing records to next.
+type RedactingHandler struct {
+	next Handler
+	r    *Redactor
+}
+
+// NewRedactingHandler wraps next with redactor r (NewRedactor() defaults when nil).
+func NewRedactingHandler(next Handler, r *Redactor) *RedactingHandler {
+	if r == nil {
+		r = NewRedactor()
+	}
+	return &RedactingHandler{next: next, r: r}
+}
+
+func (h *RedactingHandler) Handle(r Record) error {
+	return h.next.Handle(h.r.RedactRecord(r))
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret_AlwaysRedacted(t *testing.T) {
+	s := Secret("hunter2")
+	assert.Equal(t, RedactedText, fmt.Sprint(s))
+	assert.Equal(t, "[REDACTED] [REDACTED] [REDACTED]", fmt.Sprintf("%v %s %#v", s, s, s))
+
+	var buf bytes.Buffer
+	_ = NewJSONHandler(&buf).Handle(Record{Message: "m", Attrs: []Attr{{Key: "pw", Value: s}}})
+	assert.Contains(t, buf.String(), `"pw":"[REDACTED]"`)
+}
+
+func TestRedactor_KeysAndValues(t *testing.T) {
+	x := NewRedactor()
+	attrs := []Attr{
+		{Key: "user", Value: "alice"},
+		{Key: "Password", Value: "hunter2"},
+		{Key: "X-Api-Key", Value: "k"},
+		{Key: "note", Value: "mail bob@example.com card 4111 1111 1111 1111 id 1234567890123"},
+		{Key: "auth", Value: errors.New("Bearer abc.def")},
+		{Key: "jwt", Value: "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln"},
+		{Key: "n", Value: 42},
+	}
+	out := x.RedactAttrs(attrs)
+	assert.Equal(t, "alice", out[0].Value)
+	assert.Equal(t, RedactedText, out[1].Value)
+	assert.Equal(t, RedactedText, out[2].Value)
+	assert.Equal(t, "mail [REDACTED] card [REDACTED] id 1234567890123", out[3].Value)
+	assert.Equal(t, RedactedText, out[4].Value)
+	assert.Equal(t, RedactedText, out[5].Value)
+	assert.Equal(t, 42, out[6].Value)
+	// input untouched
+	assert.Equal(t, "hunter2", attrs[1].Value)
+}
+
+func TestRedactor_Modes(t *testing.T) {
+	x := NewRedactor(
+		RedactRule{Key: "email", Mode: RedactHash},
+		RedactRule{KeyPattern: regexp.MustCompile(`(?i)^ssn$`), Mode: RedactDrop},
+		RedactRule{Value: regexp.MustCompile(`\d{3}-\d{4}`), Mode: RedactDrop},
+	)
+	out := x.RedactAttrs([]Attr{{Key: "email", Value: "a@b.co"}, {Key: "SSN", Value: "1"}, {Key: "msg", Value: "call 555-1234"}})
+	assert.Len(t, out, 2)
+	h, _ := out[0].Value.(string)
+	assert.True(t, strings.HasPrefix(h, "sha256:"))
+	assert.Equal(t, h, x.RedactAttrs([]Attr{{Key: "email", Value: "a@b.co"}})[0].Value, "hash is stable")
+	assert.Equal(t, "call ", out[1].Value)
+}
+
+func TestRedactor_BodyAndQuery(t *testing.T) {
+	x := NewRedactor()
+	assert.Equal(t, `{"password":"[REDACTED]","user":"bob"}`, x.RedactBody(`{"user":"bob","password":"pw"}`))
+	assert.Equal(t, `{"list":[{"token":"[REDACTED]"}]}`, x.RedactBody(`{"list":[{"token":"t"}]}`))
+	assert.Equal(t, "plain [REDACTED]", x.RedactBody("plain bob@example.com"))
+	// truncated previews do not parse but key rules still apply
+	assert.Equal(t, `{"user":"bob","password":"[REDACTED]"`, x.RedactBody(`{"user":"bob","password":"pw`))
+	assert.Equal(t, `{"password":"[REDACTED]", "api_key":"[REDACTED]", "n":1, "user":"b…`,
+		x.RedactBody(`{"password":"p\"w", "api_key":12345, "n":1, "user":"b…`))
+	assert.Equal(t, `[{"password":"[REDACTED]","user":"bob"},{"token":"[REDACTED]"}]`,
+		x.RedactBody(`[{"user":"bob","password":"x"},{"token":"y"}]`))
+	assert.Equal(t, "user=bob&password=[REDACTED]&token=[REDACTED]", x.RedactBody("user=bob&password=x&token=y"))
+	assert.Equal(t, "password=[REDACTED]&n=1…(truncated)", x.RedactBody("password=x&n=1…(truncated)"))
+	drop := NewRedactor(RedactRule{Key: "token", Mode: RedactDrop})
+	assert.Equal(t, `{"a":1, "b":2`, drop.RedactBody(`{"a":1, "token":"t", "b":2`))
+	assert.Equal(t, "a=1&access_token=[REDACTED]&b", x.RedactQuery("a=1&access_token=xyz&b"))
+	assert.Equal(t, "", x.RedactQuery(""))
+}
+
+func TestRedactingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(io.Discard, "", 0)
+	l.AddHandler(LevelAll, NewRedactingHandler(&WriterHandler{w: &buf}, nil))
+	l.Info("login for bob@example.com", "password", "pw", "secret", Secret("s"), "meta", map[string]any{"token": "t", "ok": 1})
+	assert.Equal(t, "INFO     login for [REDACTED] password=[REDACTED] secret=[REDACTED] meta=map[ok:1 token:[REDACTED]]\n", buf.String())
+}
+
+func TestHTTPLogging_Redactor(t *testing.T) {
+	withStdReset(t, func() {
+		var buf bytes.Buffer
+		SetOutput(&buf)
+		SetFlags(0)
+		h := HTTPLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			&HTTPLogOptions{Mode: ColorOff, IncludeQuery: true, LogPostBody: true, Redactor: NewRedactor()})
+		req := httptest.NewRequest(http.MethodPost, "/login?api_key=k1&x=1", strings.NewReader(`{"password":"pw"}`))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		out := buf.String()
+		assert.Contains(t, out, "/login?api_key=[REDACTED]&x=1")
+		assert.Contains(t, out, `body={"password":"[REDACTED]"}`)
+		assert.NotContains(t, out, "pw\"")
+		assert.NotContains(t, out, "k1")
+
+		buf.Reset()
+		req = httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("user=bob&password=hunter2"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Contains(t, buf.String(), "body=user=bob&password=[REDACTED]")
+		assert.NotContains(t, buf.String(), "hunter2")
+	})
+}
+
+func TestRedactor_DoesNotModifyInput(t *testing.T) {
+	x := NewRedactor()
+	inner := map[string]any{"token": "t", "id": 1}
+	list := []any{map[string]any{"password": "pw"}}
+	attrs := []Attr{{Key: "m", Value: map[string]any{"meta": inner, "list": list}}}
+
+	out := x.RedactAttrs(attrs)
+	assert.Equal(t, map[string]any{"token": "t", "id": 1}, inner)
+	assert.Equal(t, []any{map[string]any{"password": "pw"}}, list)
+	assert.Equal(t, map[string]any{
+		"meta": map[string]any{"token": RedactedText, "id": 1},
+		"list": []any{map[string]any{"password": RedactedText}},
+	}, out[0].Value)
+}
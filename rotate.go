@@ -0,0 +1,97 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an io.WriteCloser that appends to a file and rotates it
+// once it would exceed MaxBytes: path becomes path.1, path.1 becomes path.2
+// and so on, keeping at most Backups old files.
+type RotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewRotatingFile opens path for appending, creating parent directories.
+// maxBytes <= 0 disables rotation; backups < 1 keeps one old file.
+func NewRotatingFile(path string, maxBytes int64, backups int) (*RotatingFile, error) {
+	if backups < 1 {
+		backups = 1
+	}
+	rf := &RotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := OpenFileAppend(rf.path, 0o644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	rf.f, rf.size = f, st.Size()
+	return nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.maxBytes > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxBytes {
+		if err := rf.rotate(); err != nil && rf.f == nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current file aside and opens a new one. If the file cannot
+// be moved, writing continues in the current file and rotation is retried once
+// another maxBytes have been written; rf.f is nil only if no file could be
+// reopened.
+func (rf *RotatingFile) rotate() error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+	rf.f = nil
+	for i := rf.backups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+		if openErr := rf.open(); openErr != nil {
+			return openErr
+		}
+		rf.size = 0
+		return err
+	}
+	return rf.open()
+}
+
+// Close closes the current file. Later writes fail with os.ErrClosed.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
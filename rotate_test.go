@@ -0,0 +1,84 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "app.log")
+	rf, err := NewRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		_, err := rf.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, rf.Close())
+
+	read := func(p string) string {
+		b, err := os.ReadFile(p)
+		require.NoError(t, err)
+		return string(b)
+	}
+	assert.Equal(t, "dddddd\n", read(path))
+	assert.Equal(t, "cccccc\n", read(path+".1"))
+	assert.Equal(t, "bbbbbb\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+
+	_, err = rf.Write([]byte("late\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFileAppendsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+	rf, err := NewRotatingFile(path, 0, 1)
+	require.NoError(t, err)
+	_, _ = rf.Write([]byte("new\n"))
+	require.NoError(t, rf.Close())
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "old\nnew\n", string(b))
+}
+
+func TestRotatingFileKeepsWritingWhenRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	// a non-empty directory where the backup should go makes the rename fail
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "x"), 0o755))
+	rf, err := NewRotatingFile(path, 10, 1)
+	require.NoError(t, err)
+	defer rf.Close()
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n"} {
+		_, err := rf.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "aaaaaa\nbbbbbb\ncccccc\n", string(b))
+}
+
+func TestRotatingFileReadOnlyDir(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rf, err := NewRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer rf.Close()
+	_, err = rf.Write([]byte("aaaaaa\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.Chmod(dir, 0o555))
+	t.Cleanup(func() { _ = os.Chmod(dir, 0o755) })
+	for _, s := range []string{"bbbbbb\n", "cccccc\n"} {
+		_, err := rf.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "aaaaaa\nbbbbbb\ncccccc\n", string(b))
+	assert.NoFileExists(t, path+".1")
+}
@@ -0,0 +1,280 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// UnaryInterceptor is function-shaped middleware for unary RPCs. next invokes
+// the rest of the chain. A grpc-go adapter only has to map
+// grpc.UnaryServerInfo.FullMethod to method and grpc.UnaryHandler to next.
+type UnaryInterceptor func(ctx context.Context, method string, req any, next func(context.Context, any) (any, error)) (any, error)
+
+// RPCStream is the subset of a message stream the stream interceptor observes.
+// grpc.ServerStream and grpc.ClientStream satisfy it.
+type RPCStream interface {
+	SendMsg(m any) error
+	RecvMsg(m any) error
+}
+
+// StreamInterceptor is function-shaped middleware for streaming RPCs. next
+// receives a stream that counts messages; adapters should route SendMsg and
+// RecvMsg of the framework stream through it.
+type StreamInterceptor func(ctx context.Context, method string, stream RPCStream, next func(context.Context, RPCStream) error) error
+
+// RPCLevels sets the levels the RPC interceptors log at.
+type RPCLevels struct {
+	Start    Level // start line
+	Success  Level // finished without error
+	Canceled Level // context canceled or deadline exceeded
+	Error    Level // any other error
+}
+
+// DefaultRPCLevels returns the levels used when RPCLogOptions.Levels is nil:
+// DEBUG for the start line, INFO on success, WARN for cancellations and ERROR
+// for other errors.
+func DefaultRPCLevels() RPCLevels {
+	return RPCLevels{
+		Start:    LevelDebug,
+		Success:  LevelInfo,
+		Canceled: LevelWarn,
+		Error:    LevelError,
+	}
+}
+
+func (lv *RPCLevels) forError(err error) Level {
+	switch {
+	case err == nil:
+		return lv.Success
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return lv.Canceled
+	default:
+		return lv.Error
+	}
+}
+
+// RPCLogOptions customizes UnaryLogging and StreamLogging.
+type RPCLogOptions struct {
+	// Logger receives the log lines (default: the package default logger).
+	Logger *Logger
+	// Levels sets the start line level and the finish levels (default DefaultRPCLevels()).
+	Levels *RPCLevels
+	// ErrorLevel, when set, chooses the finish level instead of Levels, e.g. to
+	// log NotFound at INFO. It is called with a nil error on success.
+	ErrorLevel func(err error) Level
+	// Code, when set, adds a code attr to the finish line, e.g.
+	// func(err error) string { return status.Code(err).String() }.
+	Code func(err error) string
+	// SkipStartLine disables the line logged before the handler runs.
+	SkipStartLine bool
+	// SkipMethods lists methods that are not logged, using HTTPRoute.Path
+	// patterns (e.g. "/grpc.health.v1.Health/*").
+	SkipMethods []string
+	// LogPayloads adds JSON previews of unary requests and responses.
+	LogPayloads bool
+	// MaxPayloadBytes caps payload previews (default 64KB when zero or negative).
+	MaxPayloadBytes int
+	// Redactor scrubs payload previews (default NewRedactor()).
+	Redactor *Redactor
+	// RequestID attaches a request id to the context and log lines, reusing a
+	// valid id already stored with ContextWithRequestID.
+	RequestID bool
+	// GenerateRequestID creates new request ids (default NewRequestID).
+	GenerateRequestID func() string
+}
+
+// rpcLogger holds the resolved options shared by both interceptors.
+type rpcLogger struct {
+	o      RPCLogOptions
+	lg     *Logger
+	levels RPCLevels
+}
+
+func newRPCLogger(opts *RPCLogOptions) *rpcLogger {
+	var o RPCLogOptions
+	if opts != nil {
+		o = *opts
+	}
+	rl := &rpcLogger{lg: o.Logger, levels: DefaultRPCLevels()}
+	if rl.lg == nil {
+		rl.lg = std
+	}
+	if o.Levels != nil {
+		rl.levels = *o.Levels
+	}
+	if o.ErrorLevel == nil {
+		o.ErrorLevel = rl.levels.forError
+	}
+	if o.MaxPayloadBytes <= 0 {
+		o.MaxPayloadBytes = defaultMaxBodyBytes
+	}
+	if o.Redactor == nil {
+		o.Redactor = NewRedactor()
+	}
+	if o.GenerateRequestID == nil {
+		o.GenerateRequestID = NewRequestID
+	}
+	rl.o = o
+	return rl
+}
+
+func (rl *rpcLogger) skip(method string) bool {
+	for _, p := range rl.o.SkipMethods {
+		if matchPath(p, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// begin stores the request id and a method-scoped logger in ctx.
+func (rl *rpcLogger) begin(ctx context.Context, method string) (context.Context, string) {
+	base := rl.o.Logger
+	if base == nil {
+		base = FromContext(ctx)
+	}
+	kv := []any{"method", method}
+	var reqID string
+	if rl.o.RequestID {
+		reqID = RequestIDFromContext(ctx)
+		if !validRequestID(reqID) {
+			reqID = rl.o.GenerateRequestID()
+		}
+		ctx = ContextWithRequestID(ctx, reqID)
+		kv = append(kv, "request_id", reqID)
+	}
+	return NewContext(ctx, base.With(kv...)), reqID
+}
+
+func (rl *rpcLogger) finish(method string, err error, attrs []any) {
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	if rl.o.Code != nil {
+		attrs = append(attrs, "code", rl.o.Code(err))
+	}
+	rl.lg.Log(rl.o.ErrorLevel(err), method, attrs...)
+}
+
+// preview renders v as redacted JSON (or %+v when it does not marshal),
+// truncated to MaxPayloadBytes.
+func (rl *rpcLogger) preview(v any) string {
+	if v == nil {
+		return ""
+	}
+	var s string
+	if b, err := json.Marshal(v); err == nil {
+		s = rl.o.Redactor.RedactBody(string(b))
+	} else {
+		s = rl.o.Redactor.RedactString(fmt.Sprintf("%+v", v))
+	}
+	if len(s) <= rl.o.MaxPayloadBytes {
+		return s
+	}
+	cut := rl.o.MaxPayloadBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…(truncated)"
+}
+
+// UnaryLogging returns a UnaryInterceptor that logs a start line at DEBUG and
+// a finish line with the duration at a level chosen from the returned error,
+// mirroring HTTPLogging. The context passed to next carries a Logger scoped to
+// the method (see FromContext).
+func UnaryLogging(opts *RPCLogOptions) UnaryInterceptor {
+	rl := newRPCLogger(opts)
+	return func(ctx context.Context, method string, req any, next func(context.Context, any) (any, error)) (any, error) {
+		if rl.skip(method) {
+			return next(ctx, req)
+		}
+		start := time.Now()
+		ctx, reqID := rl.begin(ctx, method)
+		var idAttrs []any
+		if reqID != "" {
+			idAttrs = []any{"request_id", reqID}
+		}
+		if !rl.o.SkipStartLine {
+			attrs := append([]any{}, idAttrs...)
+			if rl.o.LogPayloads {
+				if p := rl.preview(req); p != "" {
+					attrs = append(attrs, "req", p)
+				}
+			}
+			rl.lg.Log(rl.levels.Start, method, attrs...)
+		}
+
+		resp, err := next(ctx, req)
+
+		attrs := append([]any{"duration", time.Since(start).String()}, idAttrs...)
+		if rl.o.LogPayloads {
+			if rl.o.SkipStartLine {
+				if p := rl.preview(req); p != "" {
+					attrs = append(attrs, "req", p)
+				}
+			}
+			if p := rl.preview(resp); p != "" && err == nil {
+				attrs = append(attrs, "resp", p)
+			}
+		}
+		rl.finish(method, err, attrs)
+		return resp, err
+	}
+}
+
+// StreamLogging returns a StreamInterceptor that logs a start line and a
+// finish line with the duration and the number of messages sent and received.
+func StreamLogging(opts *RPCLogOptions) StreamInterceptor {
+	rl := newRPCLogger(opts)
+	return func(ctx context.Context, method string, stream RPCStream, next func(context.Context, RPCStream) error) error {
+		if rl.skip(method) {
+			return next(ctx, stream)
+		}
+		start := time.Now()
+		ctx, reqID := rl.begin(ctx, method)
+		var idAttrs []any
+		if reqID != "" {
+			idAttrs = []any{"request_id", reqID}
+		}
+		if !rl.o.SkipStartLine {
+			rl.lg.Log(rl.levels.Start, method, append([]any{"stream", true}, idAttrs...)...)
+		}
+
+		cs := &countingStream{RPCStream: stream}
+		err := next(ctx, cs)
+
+		attrs := []any{"sent", cs.sent.Load(), "received", cs.received.Load(), "duration", time.Since(start).String()}
+		rl.finish(method, err, append(attrs, idAttrs...))
+		return err
+	}
+}
+
+// countingStream counts messages successfully sent and received.
+type countingStream struct {
+	RPCStream
+	sent, received atomic.Uint64
+}
+
+func (s *countingStream) SendMsg(m any) error {
+	err := s.RPCStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
+
+func (s *countingStream) RecvMsg(m any) error {
+	err := s.RPCStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+	return err
+}
+
+// Unwrap returns the stream passed to the interceptor.
+func (s *countingStream) Unwrap() RPCStream { return s.RPCStream }
@@ -0,0 +1,126 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnaryLogging(t *testing.T) {
+	var buf bytes.Buffer
+	ic := UnaryLogging(&RPCLogOptions{
+		Logger:            New(&buf, "", 0),
+		LogPayloads:       true,
+		RequestID:         true,
+		GenerateRequestID: func() string { return "rid-9" },
+		Code: func(err error) string {
+			if err == nil {
+				return "OK"
+			}
+			return "Internal"
+		},
+	})
+	type loginReq struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+	resp, err := ic(context.Background(), "/auth.Auth/Login", loginReq{"bob", "hunter2"}, func(ctx context.Context, req any) (any, error) {
+		assert.Equal(t, "rid-9", RequestIDFromContext(ctx))
+		FromContext(ctx).Info("inside")
+		return map[string]string{"id": "u1"}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "u1"}, resp)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, `DEBUG    /auth.Auth/Login request_id=rid-9 req={"password":"[REDACTED]","user":"bob"}`, lines[0])
+	assert.Equal(t, "INFO     inside method=/auth.Auth/Login request_id=rid-9", lines[1])
+	assert.Contains(t, lines[2], "INFO     /auth.Auth/Login duration=")
+	assert.Contains(t, lines[2], `request_id=rid-9 resp={"id":"u1"} code=OK`)
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestUnaryLogging_ErrorLevels(t *testing.T) {
+	var buf bytes.Buffer
+	notFound := errors.New("not found")
+	ic := UnaryLogging(&RPCLogOptions{Logger: New(&buf, "", 0), SkipStartLine: true})
+	for _, err := range []error{fmt.Errorf("rpc: %w", context.DeadlineExceeded), notFound} {
+		_, got := ic(context.Background(), "/svc/M", nil, func(context.Context, any) (any, error) { return nil, err })
+		assert.Equal(t, err, got)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "WARN     /svc/M duration="), lines[0])
+	assert.Contains(t, lines[0], "error=rpc: context deadline exceeded")
+	assert.True(t, strings.HasPrefix(lines[1], "ERROR    /svc/M duration="), lines[1])
+
+	buf.Reset()
+	ic = UnaryLogging(&RPCLogOptions{
+		Logger:        New(&buf, "", 0),
+		SkipStartLine: true,
+		SkipMethods:   []string{"/grpc.health.v1.Health/*"},
+		ErrorLevel: func(err error) Level {
+			if errors.Is(err, notFound) {
+				return LevelInfo
+			}
+			return LevelError
+		},
+	})
+	_, _ = ic(context.Background(), "/grpc.health.v1.Health/Check", nil, func(context.Context, any) (any, error) { return nil, nil })
+	_, _ = ic(context.Background(), "/svc/M", nil, func(context.Context, any) (any, error) { return nil, notFound })
+	assert.True(t, strings.HasPrefix(buf.String(), "INFO     /svc/M duration="), buf.String())
+	assert.NotContains(t, buf.String(), "Health")
+}
+
+func TestRPCPayloadTruncation(t *testing.T) {
+	rl := newRPCLogger(&RPCLogOptions{MaxPayloadBytes: 8})
+	assert.Equal(t, `"abcdef"`, rl.preview("abcdef"))
+	assert.Equal(t, `"abcdefg…(truncated)`, rl.preview("abcdefghij"))
+	assert.Equal(t, `"ééé…(truncated)`, rl.preview("éééé"))
+	assert.Equal(t, "", rl.preview(nil))
+	assert.Equal(t, "(1+2i)", rl.preview(complex(1, 2)), "values JSON cannot encode fall back to %+v")
+}
+
+type fakeStream struct {
+	in []string
+}
+
+func (s *fakeStream) SendMsg(m any) error { return nil }
+
+func (s *fakeStream) RecvMsg(m any) error {
+	if len(s.in) == 0 {
+		return io.EOF
+	}
+	*m.(*string), s.in = s.in[0], s.in[1:]
+	return nil
+}
+
+func TestStreamLogging(t *testing.T) {
+	var buf bytes.Buffer
+	ic := StreamLogging(&RPCLogOptions{Logger: New(&buf, "", 0)})
+	err := ic(context.Background(), "/chat.Chat/Echo", &fakeStream{in: []string{"a", "b"}}, func(ctx context.Context, s RPCStream) error {
+		for {
+			var m string
+			if err := s.RecvMsg(&m); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := s.SendMsg(m); err != nil {
+				return err
+			}
+		}
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "DEBUG    /chat.Chat/Echo stream=true", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "INFO     /chat.Chat/Echo sent=2 received=2 duration="), lines[1])
+}
@@ -3,30 +3,35 @@ package log
 import (
 	"io"
 	"os"
+	"regexp"
 	"time"
 )
 
-// SetExitFunc sets the function used to exit the process for Fatal variants.
-// Pass nil to restore default os.Exit.
+// SetExitFunc sets the function used to exit the process for Fatal variants
+// of loggers without WithExitFunc. Pass nil to restore default os.Exit.
+// Parallel tests should prefer New(..., WithExitFunc(f)).
 func SetExitFunc(f func(int)) {
 	if f == nil {
-		exitFunc = os.Exit
-		return
+		f = os.Exit
 	}
+	hooksMu.Lock()
 	exitFunc = f
+	hooksMu.Unlock()
 }
 
 // SetNowFunc sets the time source for newly created loggers and updates the
-// default logger. Pass nil to restore time.Now.
+// default logger. Pass nil to restore time.Now. Parallel tests should prefer
+// New(..., WithClock(c)).
 func SetNowFunc(fn func() time.Time) {
 	if fn == nil {
-		globalNow = time.Now
-	} else {
-		globalNow = fn
+		fn = time.Now
 	}
+	hooksMu.Lock()
+	globalNow = fn
+	hooksMu.Unlock()
 	// Update default logger's now func as well
 	std.mu.Lock()
-	std.now = globalNow
+	std.now = fn
 	std.mu.Unlock()
 }
 
@@ -40,3 +45,55 @@ func SetTestingMode(on bool) {
 	}
 	SetOutput(os.Stderr)
 }
+
+// normalizers rewrite volatile parts of log output, in order.
+var normalizers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<time>"},
+	{regexp.MustCompile(`\d{4}/\d{2}/\d{2}`), "<date>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<time>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(request_id"?[=:]\s*"?)[^\s",}]+`), "${1}<request_id>"},
+	{regexp.MustCompile(`\b(pid"?[=:]\s*)\d+`), "${1}<pid>"},
+	{regexp.MustCompile(`(?:[A-Za-z]:)?[\w./\\-]*?([\w.-]+\.go):\d+`), "$1:<line>"},
+	{regexp.MustCompile(`\b\d+(\.\d+)?(ns|µs|us|ms|s|m|h)(\d+(\.\d+)?(ns|µs|us|ms|s|m|h))*\b`), "<duration>"},
+}
+
+// Normalize replaces the parts of log output that change from run to run with
+// stable placeholders: timestamps (<date>, <time>), durations (<duration>),
+// source paths (file.go:<line>), pid attrs (<pid>), UUIDs (<uuid>) and
+// request_id values (<request_id>).
+func Normalize(s string) string {
+	for _, n := range normalizers {
+		s = n.re.ReplaceAllString(s, n.repl)
+	}
+	return s
+}
+
+// normalizingWriter applies Normalize to each write. Handlers write whole
+// records per call, so placeholders are never split.
+type normalizingWriter struct{ w io.Writer }
+
+func (n normalizingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(n.w, Normalize(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetGoldenMode routes the default logger to w with its output normalized as
+// by Normalize, for comparing against golden files. It returns a function that
+// restores the previous outputs.
+func SetGoldenMode(w io.Writer) (restore func()) {
+	std.mu.Lock()
+	prev := std.outputs
+	std.outputs = []output{{h: &WriterHandler{w: normalizingWriter{w}}, min: LevelDebug}}
+	std.mu.Unlock()
+	return func() {
+		std.mu.Lock()
+		std.outputs = prev
+		std.mu.Unlock()
+	}
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -33,3 +34,22 @@ func TestSetTestingModeToggle(t *testing.T) {
 	SetTestingMode(true)
 	SetTestingMode(false)
 }
+
+func TestNormalize(t *testing.T) {
+	in := "2024/03/05 14:07:09.123456 INFO     [svc] /home/ci/src/app/server.go:42: started pid=81234 took 1m2.5s " +
+		"request_id=5f0c2d4e-1b6a-4c8e-9d2f-3a4b5c6d7e8f at 2024-03-05T14:07:09.5Z lat=350µs size=12\n" +
+		`{"time":"2024-03-05T14:07:09+02:00","request_id":"abc-123","pid":77,"source":"C:\src\x.go:9","duration":"15ms"}`
+	want := "<date> <time> INFO     [svc] server.go:<line>: started pid=<pid> took <duration> " +
+		"request_id=<request_id> at <time> lat=<duration> size=12\n" +
+		`{"time":"<time>","request_id":"<request_id>","pid":<pid>,"source":"x.go:<line>","duration":"<duration>"}`
+	assert.Equal(t, want, Normalize(in))
+	assert.Equal(t, want, Normalize(want), "normalizing is idempotent")
+}
+
+func TestSetGoldenMode(t *testing.T) {
+	var buf strings.Builder
+	restore := SetGoldenMode(&buf)
+	Info("served", "duration", 3*time.Millisecond, "pid", 4242)
+	restore()
+	assert.Equal(t, "<date> <time> INFO     served duration=<duration> pid=<pid>\n", buf.String())
+}
@@ -7,8 +7,12 @@ import (
 )
 
 // formatTimestamp renders a timestamp using stdlib log flags for compatibility.
-// Matches behaviors of LUTC, Ldate, Ltime, and Lmicroseconds.
+// Matches behaviors of LUTC, Ldate, Ltime, and Lmicroseconds. A zero time
+// renders as "".
 func formatTimestamp(t time.Time, flags int) string {
+	if t.IsZero() {
+		return ""
+	}
 	if flags&LUTC != 0 {
 		t = t.UTC()
 	}
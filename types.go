@@ -8,6 +8,11 @@ type Attr struct {
 	Value any
 }
 
+// StackTrace is a multi-line attr value such as a goroutine dump. Text
+// handlers write it as a block, with continuation lines indented, instead of
+// quoting it onto one line.
+type StackTrace string
+
 // Record is a lightweight log record passed to Handlers.
 type Record struct {
 	Time    time.Time